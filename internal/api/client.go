@@ -1,9 +1,9 @@
 package api
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,6 +20,11 @@ type Client struct {
 	apiKey     string
 	baseURL    string
 	httpClient *http.Client
+	retry      RetryPolicy
+	cooldown   cooldown
+
+	requestLimiter *RateLimiter
+	pageLimiter    *RateLimiter
 }
 
 // ClientOption is a function that configures the client
@@ -32,6 +38,7 @@ func NewClient(apiKey string, opts ...ClientOption) *Client {
 		httpClient: &http.Client{
 			Timeout: 5 * time.Minute,
 		},
+		retry: DefaultRetryPolicy(),
 	}
 
 	for _, opt := range opts {
@@ -67,31 +74,46 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
 }
 
+// API error classes, matched with errors.Is against an *APIError
+var (
+	ErrAuthentication = errors.New("authentication failed")
+	ErrBadRequest     = errors.New("bad request")
+	ErrNotFound       = errors.New("not found")
+	ErrRateLimited    = errors.New("rate limited")
+	ErrServer         = errors.New("server error")
+	ErrNetwork        = errors.New("network error")
+)
+
+// Unwrap returns the error class for the status code, if any
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrAuthentication
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= 500:
+		return ErrServer
+	case e.StatusCode >= 400:
+		return ErrBadRequest
+	default:
+		return nil
+	}
+}
+
 // Parse sends a synchronous parse request
 func (c *Client) Parse(ctx context.Context, req *ParseRequest) (*ParseResponse, error) {
-	body, contentType, err := buildMultipartForm(req)
-	if err != nil {
+	if err := c.waitPages(ctx, req); err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/document-digitization", body)
+	resp, err := c.do(ctx, http.MethodPost, c.baseURL+"/document-digitization", multipartBody(req))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-	httpReq.Header.Set("Content-Type", contentType)
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, c.parseError(resp)
-	}
-
 	var parseResp ParseResponse
 	if err := json.NewDecoder(resp.Body).Decode(&parseResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
@@ -102,29 +124,16 @@ func (c *Client) Parse(ctx context.Context, req *ParseRequest) (*ParseResponse,
 
 // ParseAsync sends an asynchronous parse request
 func (c *Client) ParseAsync(ctx context.Context, req *ParseRequest) (*AsyncResponse, error) {
-	body, contentType, err := buildMultipartForm(req)
-	if err != nil {
+	if err := c.waitPages(ctx, req); err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/document-digitization/async", body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-	httpReq.Header.Set("Content-Type", contentType)
-
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(ctx, http.MethodPost, c.baseURL+"/document-digitization/async", multipartBody(req))
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-		return nil, c.parseError(resp)
-	}
-
 	var asyncResp AsyncResponse
 	if err := json.NewDecoder(resp.Body).Decode(&asyncResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
@@ -137,23 +146,12 @@ func (c *Client) ParseAsync(ctx context.Context, req *ParseRequest) (*AsyncRespo
 func (c *Client) GetStatus(ctx context.Context, requestID string) (*StatusResponse, error) {
 	url := fmt.Sprintf("%s/document-digitization/async/%s", c.baseURL, requestID)
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, c.parseError(resp)
-	}
-
 	var statusResp StatusResponse
 	if err := json.NewDecoder(resp.Body).Decode(&statusResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
@@ -166,29 +164,146 @@ func (c *Client) GetStatus(ctx context.Context, requestID string) (*StatusRespon
 func (c *Client) GetResult(ctx context.Context, requestID string) (*ParseResponse, error) {
 	url := fmt.Sprintf("%s/document-digitization/async/%s/result", c.baseURL, requestID)
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	resp, err := c.do(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var parseResp ParseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parseResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &parseResp, nil
+}
+
+// waitPages blocks until the page budget allows uploading the document
+func (c *Client) waitPages(ctx context.Context, req *ParseRequest) error {
+	if c.pageLimiter == nil {
+		return nil
+	}
+	if _, err := os.Stat(req.FilePath); err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	if err := c.pageLimiter.WaitN(ctx, EstimatePages(req.FilePath)); err != nil {
+		return fmt.Errorf("request canceled: %w", err)
+	}
+	return nil
+}
+
+// bodyFunc builds a fresh request body, its content type and length for each attempt.
+// A negative length means the size is unknown.
+type bodyFunc func() (io.ReadCloser, string, int64, error)
+
+// multipartBody returns a bodyFunc that streams the parse request as a multipart form
+func multipartBody(req *ParseRequest) bodyFunc {
+	return func() (io.ReadCloser, string, int64, error) {
+		return buildMultipartForm(req)
+	}
+}
+
+// do sends a request, retrying transient failures according to the retry policy.
+// On success the caller owns the response body.
+func (c *Client) do(ctx context.Context, method, url string, newBody bodyFunc) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.cooldown.wait(ctx); err != nil {
+			return nil, fmt.Errorf("request canceled: %w", err)
+		}
+		if c.requestLimiter != nil {
+			if err := c.requestLimiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("request canceled: %w", err)
+			}
+		}
+
+		resp, err := c.send(ctx, method, url, newBody)
+
+		var retryAfter string
+		switch {
+		case err != nil:
+			var reqErr *requestError
+			if !errors.As(err, &reqErr) || !c.retry.shouldRetryError(ctx, reqErr.err) {
+				return nil, err
+			}
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return resp, nil
+		default:
+			err = c.parseError(resp)
+			retryAfter = resp.Header.Get("Retry-After")
+			_ = resp.Body.Close()
+			if !c.retry.shouldRetryStatus(method, resp.StatusCode) {
+				return nil, err
+			}
+		}
+
+		if attempt >= c.retry.MaxRetries {
+			return nil, err
+		}
+
+		delay := c.retry.delay(attempt, retryAfter)
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			c.cooldown.extend(delay)
+		}
+		if c.retry.OnRetry != nil {
+			c.retry.OnRetry(attempt+1, delay, err)
+		}
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// send performs a single HTTP attempt
+func (c *Client) send(ctx context.Context, method, url string, newBody bodyFunc) (*http.Response, error) {
+	var body io.ReadCloser
+	var contentType string
+	length := int64(-1)
+	if newBody != nil {
+		var err error
+		body, contentType, length, err = newBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
+		if body != nil {
+			_ = body.Close()
+		}
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if body != nil && length >= 0 {
+		httpReq.ContentLength = length
+	}
 
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, &requestError{err: err}
 	}
-	defer func() { _ = resp.Body.Close() }()
+	return resp, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, c.parseError(resp)
-	}
+// requestError is a transport-level failure (connection refused, reset, timeout)
+type requestError struct {
+	err error
+}
 
-	var parseResp ParseResponse
-	if err := json.NewDecoder(resp.Body).Decode(&parseResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
+func (e *requestError) Error() string {
+	return fmt.Sprintf("request failed: %v", e.err)
+}
 
-	return &parseResp, nil
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+func (e *requestError) Is(target error) bool {
+	return target == ErrNetwork
 }
 
 // parseError parses an error response from the API
@@ -209,27 +324,61 @@ func (c *Client) parseError(resp *http.Response) error {
 	}
 }
 
-// buildMultipartForm builds a multipart form for the parse request
-func buildMultipartForm(req *ParseRequest) (io.Reader, string, error) {
-	// Open file
+// buildMultipartForm builds a streaming multipart form for the parse request.
+// The document is read from disk while the request is being sent, so memory use
+// does not grow with the file size. The returned length is the exact body size.
+func buildMultipartForm(req *ParseRequest) (io.ReadCloser, string, int64, error) {
 	file, err := os.Open(req.FilePath)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to open file: %w", err)
+		return nil, "", 0, fmt.Errorf("failed to open file: %w", err)
 	}
-	defer func() { _ = file.Close() }()
 
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, "", 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	// Measure the form envelope with the same boundary, without the file data
+	var counter countingWriter
+	sizer := multipart.NewWriter(&counter)
+	if err := sizer.SetBoundary(writer.Boundary()); err != nil {
+		_ = file.Close()
+		return nil, "", 0, fmt.Errorf("failed to create multipart writer: %w", err)
+	}
+	if err := writeMultipartForm(sizer, req, strings.NewReader("")); err != nil {
+		_ = file.Close()
+		return nil, "", 0, err
+	}
+	length := counter.n + info.Size()
+
+	var content io.Reader = file
+	if req.OnUploadProgress != nil {
+		content = &progressReader{r: file, total: info.Size(), fn: req.OnUploadProgress}
+	}
+
+	go func() {
+		defer func() { _ = file.Close() }()
+		_ = pw.CloseWithError(writeMultipartForm(writer, req, content))
+	}()
+
+	return pr, writer.FormDataContentType(), length, nil
+}
 
+// writeMultipartForm writes the document part and form fields, then closes the writer
+func writeMultipartForm(writer *multipart.Writer, req *ParseRequest, content io.Reader) error {
 	// Add file
 	filename := filepath.Base(req.FilePath)
 	part, err := writer.CreateFormFile("document", filename)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create form file: %w", err)
+		return fmt.Errorf("failed to create form file: %w", err)
 	}
 
-	if _, err := io.Copy(part, file); err != nil {
-		return nil, "", fmt.Errorf("failed to copy file: %w", err)
+	if _, err := io.Copy(part, content); err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
 	}
 
 	// Add form fields
@@ -239,10 +388,52 @@ func buildMultipartForm(req *ParseRequest) (io.Reader, string, error) {
 	_ = writer.WriteField("chart_recognition", strconv.FormatBool(req.ChartRecognition))
 	_ = writer.WriteField("merge_multipage_tables", strconv.FormatBool(req.MergeTables))
 	_ = writer.WriteField("coordinates", strconv.FormatBool(req.Coordinates))
+	if len(req.OutputFormats) > 0 {
+		_ = writer.WriteField("output_formats", formatListField(req.OutputFormats))
+	}
+	if len(req.Base64Categories) > 0 {
+		_ = writer.WriteField("base64_encoding", formatListField(req.Base64Categories))
+	}
 
 	if err := writer.Close(); err != nil {
-		return nil, "", fmt.Errorf("failed to close multipart writer: %w", err)
+		return fmt.Errorf("failed to close multipart writer: %w", err)
 	}
 
-	return &buf, writer.FormDataContentType(), nil
+	return nil
+}
+
+// formatListField encodes a list form field the way the API expects it, e.g. "['html', 'text']"
+func formatListField(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + v + "'"
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// progressReader reports how many bytes of the document have been read
+type progressReader struct {
+	r     io.Reader
+	sent  int64
+	total int64
+	fn    UploadProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		r.fn(r.sent, r.total)
+	}
+	return n, err
 }
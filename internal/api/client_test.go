@@ -1,8 +1,10 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -50,6 +52,8 @@ func TestClientParse(t *testing.T) {
 		// Check form values
 		assert.Equal(t, "document-parse", r.FormValue("model"))
 		assert.Equal(t, "standard", r.FormValue("mode"))
+		assert.Equal(t, "['html', 'markdown', 'text']", r.FormValue("output_formats"))
+		assert.Empty(t, r.FormValue("base64_encoding"))
 
 		// Check file
 		file, header, err := r.FormFile("document")
@@ -248,6 +252,31 @@ func TestAPIError(t *testing.T) {
 	assert.Contains(t, err.Error(), "Invalid API key")
 }
 
+func TestAPIErrorClass(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected error
+	}{
+		{http.StatusUnauthorized, ErrAuthentication},
+		{http.StatusForbidden, ErrAuthentication},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusBadRequest, ErrBadRequest},
+		{http.StatusUnprocessableEntity, ErrBadRequest},
+		{http.StatusInternalServerError, ErrServer},
+		{http.StatusServiceUnavailable, ErrServer},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var err error = fmt.Errorf("parse failed: %w", &APIError{StatusCode: tt.status})
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+
+	assert.NoError(t, (&APIError{StatusCode: http.StatusOK}).Unwrap())
+}
+
 func TestBuildMultipartForm(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "updoc-test-*")
 	require.NoError(t, err)
@@ -262,13 +291,16 @@ func TestBuildMultipartForm(t *testing.T) {
 		Model:            "document-parse",
 		Mode:             "enhanced",
 		OCR:              "force",
+		OutputFormats:    []string{"markdown"},
 		ChartRecognition: true,
 		MergeTables:      true,
 		Coordinates:      false,
+		Base64Categories: []string{"figure", "table"},
 	}
 
-	body, contentType, err := buildMultipartForm(req)
+	body, contentType, length, err := buildMultipartForm(req)
 	require.NoError(t, err)
+	defer func() { _ = body.Close() }()
 	assert.Contains(t, contentType, "multipart/form-data")
 
 	// Read and verify body contains expected fields
@@ -276,8 +308,66 @@ func TestBuildMultipartForm(t *testing.T) {
 	require.NoError(t, err)
 	bodyStr := string(bodyBytes)
 
+	assert.Equal(t, int64(len(bodyBytes)), length)
+
 	assert.Contains(t, bodyStr, "document-parse")
 	assert.Contains(t, bodyStr, "enhanced")
 	assert.Contains(t, bodyStr, "force")
 	assert.Contains(t, bodyStr, "test.pdf")
+	assert.Contains(t, bodyStr, "['markdown']")
+	assert.Contains(t, bodyStr, "['figure', 'table']")
+}
+
+func TestBuildMultipartFormProgress(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "large.pdf")
+	content := bytes.Repeat([]byte("0123456789"), 100000)
+	require.NoError(t, os.WriteFile(testFile, content, 0644))
+
+	var lastSent, lastTotal int64
+	req := NewParseRequest(testFile)
+	req.OnUploadProgress = func(sent, total int64) {
+		assert.GreaterOrEqual(t, sent, lastSent)
+		lastSent, lastTotal = sent, total
+	}
+
+	body, _, length, err := buildMultipartForm(req)
+	require.NoError(t, err)
+	defer func() { _ = body.Close() }()
+
+	n, err := io.Copy(io.Discard, body)
+	require.NoError(t, err)
+
+	assert.Equal(t, length, n)
+	assert.Equal(t, int64(len(content)), lastSent)
+	assert.Equal(t, int64(len(content)), lastTotal)
+}
+
+func TestClientParseSendsContentLength(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Greater(t, r.ContentLength, int64(0))
+		assert.Empty(t, r.TransferEncoding)
+
+		file, _, err := r.FormFile("document")
+		require.NoError(t, err)
+		defer func() { _ = file.Close() }()
+		data, err := io.ReadAll(file)
+		require.NoError(t, err)
+		assert.Equal(t, "streamed pdf content", string(data))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ParseResponse{Usage: Usage{Pages: 1}})
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.pdf")
+	require.NoError(t, os.WriteFile(testFile, []byte("streamed pdf content"), 0644))
+
+	client := NewClient("test-api-key", WithBaseURL(server.URL))
+
+	resp, err := client.Parse(context.Background(), NewParseRequest(testFile))
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Usage.Pages)
 }
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimits configures client-side throttling to stay under the API quota.
+// Zero values disable the corresponding limit.
+type RateLimits struct {
+	RequestsPerSecond float64 // requests of any kind, including status polling
+	PagesPerMinute    int     // estimated document pages uploaded by parse requests
+}
+
+// WithRateLimit throttles requests and uploaded pages made through the client
+func WithRateLimit(limits RateLimits) ClientOption {
+	return func(c *Client) {
+		c.requestLimiter = nil
+		c.pageLimiter = nil
+		if limits.RequestsPerSecond > 0 {
+			burst := limits.RequestsPerSecond
+			if burst < 1 {
+				burst = 1
+			}
+			c.requestLimiter = NewRateLimiter(limits.RequestsPerSecond, burst)
+		}
+		if limits.PagesPerMinute > 0 {
+			c.pageLimiter = NewRateLimiter(float64(limits.PagesPerMinute)/60, float64(limits.PagesPerMinute))
+		}
+	}
+}
+
+// RateLimiter is a token bucket limiter safe for concurrent use
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64 // bucket capacity
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRateLimiter creates a limiter that refills rate tokens per second up to burst.
+// The bucket starts full.
+func NewRateLimiter(rate, burst float64) *RateLimiter {
+	return &RateLimiter{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		now:    time.Now,
+	}
+}
+
+// Wait blocks until a single token is available
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n tokens are available or the context is done.
+// Requests larger than the bucket wait for a full bucket and then borrow
+// against future refills, so a single large document is never rejected.
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	for {
+		delay := l.reserve(float64(n))
+		if delay == 0 {
+			return nil
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve takes n tokens if available and returns 0, or returns how long to wait
+func (l *RateLimiter) reserve(n float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	need := n
+	if need > l.burst {
+		need = l.burst
+	}
+	if l.tokens >= need {
+		l.tokens -= n
+		return 0
+	}
+
+	return time.Duration((need - l.tokens) / l.rate * float64(time.Second))
+}
+
+// pdfScanOverlap is longer than any token matched while estimating PDF pages
+const pdfScanOverlap = 64
+
+var (
+	pdfPageRe  = regexp.MustCompile(`/Type\s*/Page[^s]`)
+	pdfCountRe = regexp.MustCompile(`/Count\s+(\d+)`)
+)
+
+// EstimatePages estimates the number of pages in a document before upload.
+// PDFs are scanned for page objects; other formats count as a single page.
+func EstimatePages(path string) int {
+	if strings.ToLower(filepath.Ext(path)) != ".pdf" {
+		return 1
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 1
+	}
+	defer func() { _ = file.Close() }()
+
+	pages, maxCount := 0, 0
+	scan := func(chunk []byte, limit int) {
+		for _, m := range pdfPageRe.FindAllIndex(chunk, -1) {
+			if m[0] < limit {
+				pages++
+			}
+		}
+		for _, m := range pdfCountRe.FindAllSubmatchIndex(chunk, -1) {
+			if m[0] >= limit {
+				continue
+			}
+			if c, err := strconv.Atoi(string(chunk[m[2]:m[3]])); err == nil && c > maxCount {
+				maxCount = c
+			}
+		}
+	}
+
+	// Matches starting in the last pdfScanOverlap bytes of a chunk are left for
+	// the next chunk, so tokens spanning a read boundary are counted exactly once
+	buf := make([]byte, 64*1024)
+	var carry []byte
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			chunk := append(carry, buf[:n]...)
+			limit := len(chunk) - pdfScanOverlap
+			if limit > 0 {
+				scan(chunk, limit)
+				carry = bytes.Clone(chunk[limit:])
+			} else {
+				carry = chunk
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 1
+		}
+	}
+	// A trailing "/Type /Page" at EOF still needs one byte after it to match
+	scan(append(carry, ' '), len(carry))
+
+	if maxCount > pages {
+		pages = maxCount
+	}
+	if pages < 1 {
+		return 1
+	}
+	return pages
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(rate, burst float64) (*RateLimiter, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(rate, burst)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	l, now := newTestLimiter(2, 2)
+
+	// Bucket starts full
+	assert.Equal(t, time.Duration(0), l.reserve(1))
+	assert.Equal(t, time.Duration(0), l.reserve(1))
+
+	// Empty bucket: one token takes half a second at 2 tokens/sec
+	assert.Equal(t, 500*time.Millisecond, l.reserve(1))
+
+	*now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, time.Duration(0), l.reserve(1))
+}
+
+func TestRateLimiterLargeRequestBorrows(t *testing.T) {
+	l, now := newTestLimiter(1, 10)
+
+	// More than the bucket holds is allowed once the bucket is full
+	assert.Equal(t, time.Duration(0), l.reserve(25))
+
+	// The debt has to be repaid before the next request
+	assert.Equal(t, 16*time.Second, l.reserve(1))
+
+	*now = now.Add(16 * time.Second)
+	assert.Equal(t, time.Duration(0), l.reserve(1))
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	l := NewRateLimiter(0.001, 1)
+	require.NoError(t, l.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.Error(t, l.Wait(ctx))
+}
+
+func TestClientRequestRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(StatusResponse{Status: "processing"})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithRateLimit(RateLimits{RequestsPerSecond: 20}),
+	)
+
+	start := time.Now()
+	for i := 0; i < 25; i++ {
+		_, err := client.GetStatus(context.Background(), "req_1")
+		require.NoError(t, err)
+	}
+
+	// 20 requests use the initial burst, the remaining 5 wait 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestWithRateLimitDisabled(t *testing.T) {
+	client := NewClient("test-key", WithRateLimit(RateLimits{}))
+
+	assert.Nil(t, client.requestLimiter)
+	assert.Nil(t, client.pageLimiter)
+}
+
+func TestEstimatePages(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	pageObj := "1 0 obj << /Type /Page /Parent 2 0 R >> endobj\n"
+	threePages := write("three.pdf", "%PDF-1.4\n<< /Type /Pages /Count 3 >>\n"+strings.Repeat(pageObj, 3))
+
+	// Page objects spread across many read chunks
+	padding := strings.Repeat("x", 70*1024)
+	spread := write("spread.pdf", "%PDF-1.4\n"+strings.Repeat(padding+pageObj, 4))
+
+	// Compressed object streams hide page objects, but the page tree count is visible
+	countOnly := write("count.pdf", "%PDF-1.5\n<< /Type /Pages /Kids [] /Count 12 >>")
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{threePages, 3},
+		{spread, 4},
+		{countOnly, 12},
+		{write("empty.pdf", ""), 1},
+		{write("image.png", "png"), 1},
+		{filepath.Join(tmpDir, "missing.pdf"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.path), func(t *testing.T) {
+			assert.Equal(t, tt.expected, EstimatePages(tt.path))
+		})
+	}
+}
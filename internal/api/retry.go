@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Retry defaults
+const (
+	DefaultMaxRetries = 3
+	DefaultBaseDelay  = 1 * time.Second
+	DefaultMaxDelay   = 30 * time.Second
+	DefaultJitter     = 0.2
+)
+
+// RetryPolicy configures automatic retries of transient failures
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt (0 disables retries)
+	BaseDelay  time.Duration // delay before the first retry, doubled on each attempt
+	MaxDelay   time.Duration // upper bound for a single delay, including Retry-After
+	Jitter     float64       // random spread applied to each delay (0.2 = ±20%)
+
+	// OnRetry is called before sleeping for the next attempt
+	OnRetry func(attempt int, delay time.Duration, err error)
+}
+
+// DefaultRetryPolicy returns the retry policy used by NewClient
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: DefaultMaxRetries,
+		BaseDelay:  DefaultBaseDelay,
+		MaxDelay:   DefaultMaxDelay,
+		Jitter:     DefaultJitter,
+	}
+}
+
+// WithRetryPolicy sets the retry policy for transient failures
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// shouldRetryStatus reports whether a response status is worth retrying.
+// Uploads (POST) are only retried when the server rejected the request
+// before processing it, so a document is never parsed and billed twice.
+func (p RetryPolicy) shouldRetryStatus(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return method == http.MethodGet
+	default:
+		return false
+	}
+}
+
+// shouldRetryError reports whether a transport error is worth retrying
+func (p RetryPolicy) shouldRetryError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// delay returns how long to wait before the given retry attempt (starting at 0).
+// A Retry-After header value takes precedence over the computed backoff.
+func (p RetryPolicy) delay(attempt int, retryAfter string) time.Duration {
+	if d, ok := parseRetryAfter(retryAfter, time.Now()); ok {
+		return p.clamp(d)
+	}
+
+	d := p.BaseDelay
+	for i := 0; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.Jitter > 0 {
+		spread := (rand.Float64()*2 - 1) * p.Jitter
+		d = time.Duration(float64(d) * (1 + spread))
+	}
+	return p.clamp(d)
+}
+
+func (p RetryPolicy) clamp(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t.Sub(now), true
+	}
+	return 0, false
+}
+
+// cooldown pauses every request sharing a client after the server reports a
+// rate limit, so concurrent callers back off together instead of each hitting 429
+type cooldown struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// extend pauses new requests for at least d
+func (c *cooldown) extend(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if until := time.Now().Add(d); until.After(c.until) {
+		c.until = until
+	}
+}
+
+// wait blocks until the cooldown has passed or the context is done
+func (c *cooldown) wait(ctx context.Context) error {
+	c.mu.Lock()
+	d := time.Until(c.until)
+	c.mu.Unlock()
+	return sleepContext(ctx, d)
+}
+
+// sleepContext waits for the given duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fastRetryPolicy(maxRetries int) RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: maxRetries,
+		BaseDelay:  time.Millisecond,
+		MaxDelay:   10 * time.Millisecond,
+	}
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	client := NewClient("test-key")
+
+	assert.Equal(t, DefaultMaxRetries, client.retry.MaxRetries)
+	assert.Equal(t, DefaultBaseDelay, client.retry.BaseDelay)
+	assert.Equal(t, DefaultMaxDelay, client.retry.MaxDelay)
+}
+
+func TestClientRetriesGetOnServerError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(StatusResponse{RequestID: "req_1", Status: "completed"})
+	}))
+	defer server.Close()
+
+	var retries []int
+	policy := fastRetryPolicy(3)
+	policy.OnRetry = func(attempt int, delay time.Duration, err error) {
+		retries = append(retries, attempt)
+	}
+	client := NewClient("test-key", WithBaseURL(server.URL), WithRetryPolicy(policy))
+
+	resp, err := client.GetStatus(context.Background(), "req_1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "completed", resp.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, []int{1, 2}, retries)
+}
+
+func TestClientRetriesParseOnRateLimit(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(32<<20))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ParseResponse{Content: Content{Markdown: "# OK"}})
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.pdf")
+	require.NoError(t, os.WriteFile(testFile, []byte("fake pdf content"), 0644))
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithRetryPolicy(fastRetryPolicy(2)))
+
+	resp, err := client.Parse(context.Background(), NewParseRequest(testFile))
+
+	require.NoError(t, err)
+	assert.Equal(t, "# OK", resp.Content.Markdown)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestClientDoesNotRetryParseOnServerError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.pdf")
+	require.NoError(t, os.WriteFile(testFile, []byte("fake pdf content"), 0644))
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithRetryPolicy(fastRetryPolicy(3)))
+
+	_, err := client.Parse(context.Background(), NewParseRequest(testFile))
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClientRetryExhausted(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithRetryPolicy(fastRetryPolicy(2)))
+
+	_, err := client.GetResult(context.Background(), "req_1")
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestClientRetryDisabled(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithRetryPolicy(fastRetryPolicy(0)))
+
+	_, err := client.GetStatus(context.Background(), "req_1")
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClientRetryStopsOnContextCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{MaxRetries: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	client := NewClient("test-key", WithBaseURL(server.URL), WithRetryPolicy(policy))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetStatus(ctx, "req_1")
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	assert.Equal(t, time.Second, policy.delay(0, ""))
+	assert.Equal(t, 2*time.Second, policy.delay(1, ""))
+	assert.Equal(t, 4*time.Second, policy.delay(2, ""))
+	assert.Equal(t, 5*time.Second, policy.delay(3, ""))
+	assert.Equal(t, 5*time.Second, policy.delay(30, ""))
+
+	// Retry-After takes precedence but is capped by MaxDelay
+	assert.Equal(t, 3*time.Second, policy.delay(0, "3"))
+	assert.Equal(t, 5*time.Second, policy.delay(0, "120"))
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		d := policy.delay(0, "")
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, 1500*time.Millisecond)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"10", 10 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			d, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+}
+
+func TestCooldownSharedAcrossRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(StatusResponse{Status: "completed"})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+
+	// A 429 seen by one request pauses every other request on the same client
+	client.cooldown.extend(50 * time.Millisecond)
+
+	start := time.Now()
+	_, err := client.GetStatus(context.Background(), "req_1")
+
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
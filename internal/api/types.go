@@ -50,6 +50,24 @@ const (
 	CategoryCaption   = "caption"
 )
 
+// Output formats returned in the parse response content
+const (
+	OutputFormatHTML     = "html"
+	OutputFormatMarkdown = "markdown"
+	OutputFormatText     = "text"
+)
+
+// ValidOutputFormats lists the formats accepted by the output_formats field
+var ValidOutputFormats = []string{OutputFormatHTML, OutputFormatMarkdown, OutputFormatText}
+
+// Categories lists all element categories
+var Categories = []string{
+	CategoryHeading1, CategoryHeading2, CategoryHeading3,
+	CategoryHeading4, CategoryHeading5, CategoryHeading6,
+	CategoryParagraph, CategoryTable, CategoryFigure, CategoryChart,
+	CategoryEquation, CategoryListItem, CategoryHeader, CategoryFooter, CategoryCaption,
+}
+
 // ParseRequest represents a document parse request
 type ParseRequest struct {
 	FilePath         string
@@ -60,8 +78,15 @@ type ParseRequest struct {
 	ChartRecognition bool
 	MergeTables      bool
 	Coordinates      bool
+	Base64Categories []string // element categories returned as base64 images
+
+	// OnUploadProgress is called as the document is uploaded (optional)
+	OnUploadProgress UploadProgressFunc
 }
 
+// UploadProgressFunc reports the number of document bytes sent out of the total
+type UploadProgressFunc func(sent, total int64)
+
 // NewParseRequest creates a new ParseRequest with default values
 func NewParseRequest(filePath string) *ParseRequest {
 	return &ParseRequest{
@@ -149,3 +174,23 @@ func IsSupportedFile(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
 	return SupportedExtensions[ext]
 }
+
+// IsValidOutputFormat checks if the format can be requested via output_formats
+func IsValidOutputFormat(format string) bool {
+	for _, v := range ValidOutputFormats {
+		if v == format {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidCategory checks if the element category is known
+func IsValidCategory(category string) bool {
+	for _, v := range Categories {
+		if v == category {
+			return true
+		}
+	}
+	return false
+}
@@ -178,3 +178,19 @@ func TestElementCategories(t *testing.T) {
 		assert.NotEmpty(t, cat)
 	}
 }
+
+func TestIsValidOutputFormat(t *testing.T) {
+	assert.True(t, IsValidOutputFormat("html"))
+	assert.True(t, IsValidOutputFormat("markdown"))
+	assert.True(t, IsValidOutputFormat("text"))
+	assert.False(t, IsValidOutputFormat("json"))
+	assert.False(t, IsValidOutputFormat(""))
+}
+
+func TestIsValidCategory(t *testing.T) {
+	for _, cat := range Categories {
+		assert.True(t, IsValidCategory(cat))
+	}
+	assert.False(t, IsValidCategory("image"))
+	assert.False(t, IsValidCategory(""))
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Polling defaults for async requests
+const (
+	DefaultPollInterval    = 2 * time.Second
+	DefaultMaxPollInterval = 30 * time.Second
+)
+
+// Async request statuses
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// WaitOptions configures how WaitForCompletion polls an async request
+type WaitOptions struct {
+	InitialDelay time.Duration // delay before the first status check (0 checks immediately)
+	Interval     time.Duration // shortest delay between status checks
+	MaxInterval  time.Duration // longest delay between status checks
+
+	// OnStatus is called with each status received, including the final one
+	OnStatus func(*StatusResponse)
+}
+
+// RequestFailedError is returned when an async request failed on the server
+type RequestFailedError struct {
+	RequestID string
+	Message   string
+}
+
+func (e *RequestFailedError) Error() string {
+	return fmt.Sprintf("request %s failed: %s", e.RequestID, e.Message)
+}
+
+// WaitForCompletion polls an async request until it finishes and returns its
+// result. It returns a *RequestFailedError if the request failed, and the
+// context's error if ctx is done first.
+func (c *Client) WaitForCompletion(ctx context.Context, requestID string, opts WaitOptions) (*ParseResponse, error) {
+	if _, err := c.WaitForStatus(ctx, requestID, opts); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.GetResult(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get result of %s: %w", requestID, err)
+	}
+	return resp, nil
+}
+
+// WaitForStatus polls an async request until it finishes and returns the
+// completed status, without downloading the result.
+//
+// While the request makes progress, the next check is timed from the observed
+// progress rate; while it does not, the delay grows by half, up to
+// opts.MaxInterval.
+func (c *Client) WaitForStatus(ctx context.Context, requestID string, opts WaitOptions) (*StatusResponse, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultPollInterval
+	}
+	if opts.MaxInterval <= 0 {
+		opts.MaxInterval = DefaultMaxPollInterval
+	}
+	opts.MaxInterval = max(opts.MaxInterval, opts.Interval)
+
+	delay := opts.InitialDelay
+	var lastDone float64
+	var lastCheck time.Time
+	for {
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		status, err := c.GetStatus(ctx, requestID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status of %s: %w", requestID, err)
+		}
+		if opts.OnStatus != nil {
+			opts.OnStatus(status)
+		}
+
+		switch status.Status {
+		case StatusCompleted:
+			return status, nil
+		case StatusFailed:
+			return nil, &RequestFailedError{RequestID: requestID, Message: status.Error}
+		}
+
+		now := time.Now()
+		done := status.fraction()
+		if lastCheck.IsZero() {
+			delay = opts.Interval
+		} else {
+			delay = nextPollDelay(delay, opts.Interval, opts.MaxInterval, lastDone, done, now.Sub(lastCheck))
+		}
+		lastDone, lastCheck = done, now
+	}
+}
+
+// fraction returns the share of the request processed so far, from 0 to 1
+func (s *StatusResponse) fraction() float64 {
+	if s.TotalPages > 0 {
+		return min(float64(s.PagesProcessed)/float64(s.TotalPages), 1)
+	}
+	return min(max(float64(s.Progress)/100, 0), 1)
+}
+
+// nextPollDelay picks the delay before the next status check. If the request
+// went from prevDone to done in elapsed, the check is timed for half the
+// estimated remaining time; otherwise the delay grows by half. The result is
+// kept within [minDelay, maxDelay].
+func nextPollDelay(delay, minDelay, maxDelay time.Duration, prevDone, done float64, elapsed time.Duration) time.Duration {
+	next := delay * 3 / 2
+	if done > prevDone && elapsed > 0 {
+		rate := (done - prevDone) / elapsed.Seconds()
+		remaining := (1 - done) / rate
+		next = time.Duration(min(remaining/2, maxDelay.Seconds()) * float64(time.Second))
+	}
+	return min(max(next, minDelay), maxDelay)
+}
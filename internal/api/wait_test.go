@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// asyncServer serves the given statuses in turn, repeating the last one, and
+// a result once the request is completed
+func asyncServer(t *testing.T, statuses ...StatusResponse) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var checks atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/result") {
+			_ = json.NewEncoder(w).Encode(ParseResponse{Content: Content{Markdown: "# Result"}, Usage: Usage{Pages: 2}})
+			return
+		}
+		i := int(checks.Add(1)) - 1
+		_ = json.NewEncoder(w).Encode(statuses[min(i, len(statuses)-1)])
+	}))
+	t.Cleanup(server.Close)
+	return server, &checks
+}
+
+func fastWait() WaitOptions {
+	return WaitOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+}
+
+func TestWaitForCompletion(t *testing.T) {
+	server, checks := asyncServer(t,
+		StatusResponse{Status: "pending"},
+		StatusResponse{Status: "processing", PagesProcessed: 1, TotalPages: 2},
+		StatusResponse{Status: "completed", PagesProcessed: 2, TotalPages: 2},
+	)
+	client := NewClient("test-api-key", WithBaseURL(server.URL))
+
+	var seen []string
+	opts := fastWait()
+	opts.OnStatus = func(status *StatusResponse) { seen = append(seen, status.Status) }
+
+	resp, err := client.WaitForCompletion(context.Background(), "req_abc123", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "# Result", resp.Content.Markdown)
+	assert.Equal(t, []string{"pending", "processing", "completed"}, seen)
+	assert.Equal(t, int32(3), checks.Load())
+}
+
+func TestWaitForCompletionFailed(t *testing.T) {
+	server, _ := asyncServer(t,
+		StatusResponse{Status: "processing"},
+		StatusResponse{Status: "failed", Error: "corrupt file"},
+	)
+	client := NewClient("test-api-key", WithBaseURL(server.URL))
+
+	_, err := client.WaitForCompletion(context.Background(), "req_abc123", fastWait())
+	var failed *RequestFailedError
+	require.ErrorAs(t, err, &failed)
+	assert.Equal(t, "req_abc123", failed.RequestID)
+	assert.Equal(t, "corrupt file", failed.Message)
+}
+
+func TestWaitForCompletionCanceled(t *testing.T) {
+	server, _ := asyncServer(t, StatusResponse{Status: "processing"})
+	client := NewClient("test-api-key", WithBaseURL(server.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := client.WaitForCompletion(ctx, "req_abc123", fastWait())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWaitForStatusInitialDelay(t *testing.T) {
+	server, checks := asyncServer(t, StatusResponse{Status: "completed"})
+	client := NewClient("test-api-key", WithBaseURL(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	opts := fastWait()
+	opts.InitialDelay = time.Hour
+	_, err := client.WaitForStatus(ctx, "req_abc123", opts)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(0), checks.Load())
+}
+
+func TestNextPollDelay(t *testing.T) {
+	const minDelay, maxDelay = time.Second, 30 * time.Second
+
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		prevDone float64
+		done     float64
+		elapsed  time.Duration
+		expected time.Duration
+	}{
+		{"no progress backs off", 4 * time.Second, 0.5, 0.5, 4 * time.Second, 6 * time.Second},
+		{"back off capped", 25 * time.Second, 0.5, 0.5, 25 * time.Second, maxDelay},
+		{"half of remaining time", 2 * time.Second, 0.25, 0.5, 2 * time.Second, 2 * time.Second},
+		{"nearly done polls soon", 10 * time.Second, 0.5, 0.99, 10 * time.Second, minDelay},
+		{"slow progress capped", 10 * time.Second, 0.0, 0.01, 10 * time.Second, maxDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextPollDelay(tt.delay, minDelay, maxDelay, tt.prevDone, tt.done, tt.elapsed)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestStatusFraction(t *testing.T) {
+	assert.Equal(t, 0.25, (&StatusResponse{PagesProcessed: 1, TotalPages: 4, Progress: 90}).fraction())
+	assert.Equal(t, 0.5, (&StatusResponse{Progress: 50}).fraction())
+	assert.Equal(t, 1.0, (&StatusResponse{Progress: 150}).fraction())
+}
@@ -0,0 +1,175 @@
+// Package batch supports batch parse runs: output path templates and the manifest used to resume them.
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ManifestName is the file name of the manifest written to the output directory
+const ManifestName = "updoc-manifest.json"
+
+// manifestVersion is the current manifest format version
+const manifestVersion = 1
+
+// Status is the processing state of a file
+type Status string
+
+// File statuses
+const (
+	StatusPending   Status = "pending"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// Entry records the outcome of processing one source file
+type Entry struct {
+	Source    string    `json:"source"`
+	Output    string    `json:"output,omitempty"`
+	Hash      string    `json:"hash,omitempty"`
+	RequestID string    `json:"request_id,omitempty"` // async request, if parsed with --async
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Manifest is the persistent record of a batch run.
+// It is safe for concurrent use.
+type Manifest struct {
+	mu   sync.Mutex
+	path string
+
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Files     []*Entry  `json:"files"`
+
+	// Options are the parse options of the run as flag values by flag name,
+	// so that a retry parses and formats the files the same way
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// New creates an empty manifest saved to path
+func New(path string) *Manifest {
+	now := time.Now()
+	return &Manifest{path: path, Version: manifestVersion, CreatedAt: now, UpdatedAt: now}
+}
+
+// Load reads the manifest at path
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	if m.Version > manifestVersion {
+		return nil, fmt.Errorf("manifest %s has unsupported version %d", path, m.Version)
+	}
+	m.path = path
+	return m, nil
+}
+
+// Path returns the file the manifest is saved to
+func (m *Manifest) Path() string {
+	return m.path
+}
+
+// SetPath changes the file the manifest is saved to
+func (m *Manifest) SetPath(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.path = path
+}
+
+// SetOptions replaces the recorded parse options
+func (m *Manifest) SetOptions(options map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Options = options
+}
+
+// Get returns a copy of the entry for source
+func (m *Manifest) Get(source string) (Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.find(source); e != nil {
+		return *e, true
+	}
+	return Entry{}, false
+}
+
+// Set adds or replaces the entry for e.Source
+func (m *Manifest) Set(e Entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e.UpdatedAt = time.Now()
+	if existing := m.find(e.Source); existing != nil {
+		*existing = e
+		return
+	}
+	m.Files = append(m.Files, &e)
+}
+
+func (m *Manifest) find(source string) *Entry {
+	for _, e := range m.Files {
+		if e.Source == source {
+			return e
+		}
+	}
+	return nil
+}
+
+// Sources returns the sources of entries with the given status, in manifest order
+func (m *Manifest) Sources(status Status) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sources []string
+	for _, e := range m.Files {
+		if e.Status == status {
+			sources = append(sources, e.Source)
+		}
+	}
+	return sources
+}
+
+// Save writes the manifest to its path, replacing the previous file atomically
+func (m *Manifest) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), ".updoc-manifest-*")
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package batch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ManifestName)
+
+	m := New(path)
+	m.Set(Entry{Source: "/docs/a.pdf", Status: StatusPending})
+	m.Set(Entry{Source: "/docs/b.pdf", Status: StatusPending})
+	m.Set(Entry{Source: "/docs/a.pdf", Output: "/out/a.md", Hash: "abc", Status: StatusCompleted})
+	m.Set(Entry{Source: "/docs/b.pdf", Status: StatusFailed, Error: "boom"})
+	m.SetOptions(map[string]string{"format": "markdown,json", "mode": "enhanced"})
+	require.NoError(t, m.Save())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, loaded.Path())
+	require.Len(t, loaded.Files, 2)
+	assert.Equal(t, map[string]string{"format": "markdown,json", "mode": "enhanced"}, loaded.Options)
+
+	a, ok := loaded.Get("/docs/a.pdf")
+	require.True(t, ok)
+	assert.Equal(t, StatusCompleted, a.Status)
+	assert.Equal(t, "/out/a.md", a.Output)
+	assert.Equal(t, "abc", a.Hash)
+	assert.False(t, a.UpdatedAt.IsZero())
+
+	assert.Equal(t, []string{"/docs/b.pdf"}, loaded.Sources(StatusFailed))
+	assert.Equal(t, []string{"/docs/a.pdf"}, loaded.Sources(StatusCompleted))
+	assert.Empty(t, loaded.Sources(StatusPending))
+
+	_, ok = loaded.Get("/docs/c.pdf")
+	assert.False(t, ok)
+}
+
+func TestManifestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Load(filepath.Join(dir, "missing.json"))
+	assert.True(t, os.IsNotExist(err))
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte("{"), 0644))
+	_, err = Load(invalid)
+	assert.Error(t, err)
+
+	future := filepath.Join(dir, "future.json")
+	require.NoError(t, os.WriteFile(future, []byte(`{"version": 99}`), 0644))
+	_, err = Load(future)
+	assert.Error(t, err)
+}
+
+func TestManifestConcurrentUpdates(t *testing.T) {
+	m := New(filepath.Join(t.TempDir(), ManifestName))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.Set(Entry{Source: filepath.Join("/docs", string(rune('a'+i))), Status: StatusCompleted})
+			assert.NoError(t, m.Save())
+		}(i)
+	}
+	wg.Wait()
+
+	loaded, err := Load(m.Path())
+	require.NoError(t, err)
+	assert.Len(t, loaded.Files, 20)
+}
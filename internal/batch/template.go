@@ -0,0 +1,157 @@
+package batch
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TemplateVars are the values substituted into an output path template
+type TemplateVars struct {
+	Dir     string    // input directory relative to the input root, "" at the root
+	RelPath string    // input path relative to the input root, without extension
+	Stem    string    // input file name without extension
+	Ext     string    // input extension without the dot, e.g. pdf
+	Format  string    // output format, e.g. markdown
+	OutExt  string    // output extension without the dot, e.g. md
+	Hash    string    // hex SHA-256 of the input file
+	Model   string    // model used to parse
+	Mode    string    // parsing mode
+	Date    time.Time // time of the run
+}
+
+// placeholders maps each supported placeholder to its value
+var placeholders = map[string]func(v TemplateVars) string{
+	"dir":     func(v TemplateVars) string { return v.Dir },
+	"relpath": func(v TemplateVars) string { return v.RelPath },
+	"stem":    func(v TemplateVars) string { return v.Stem },
+	"ext":     func(v TemplateVars) string { return v.Ext },
+	"format":  func(v TemplateVars) string { return v.Format },
+	"outext":  func(v TemplateVars) string { return v.OutExt },
+	"hash8":   func(v TemplateVars) string { return prefix(v.Hash, 8) },
+	"model":   func(v TemplateVars) string { return v.Model },
+	"mode":    func(v TemplateVars) string { return v.Mode },
+	"date":    func(v TemplateVars) string { return v.Date.Format("2006-01-02") },
+	"yyyy":    func(v TemplateVars) string { return v.Date.Format("2006") },
+	"mm":      func(v TemplateVars) string { return v.Date.Format("01") },
+	"dd":      func(v TemplateVars) string { return v.Date.Format("02") },
+}
+
+// Placeholders returns the supported placeholder names
+func Placeholders() []string {
+	names := make([]string, 0, len(placeholders))
+	for name := range placeholders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templatePart is literal text or a placeholder name
+type templatePart struct {
+	text        string
+	placeholder string
+}
+
+// Template is a parsed output path template such as {yyyy}/{stem}.{model}.md
+type Template struct {
+	raw   string
+	parts []templatePart
+}
+
+// ParseTemplate parses and validates an output path template
+func ParseTemplate(s string) (*Template, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, fmt.Errorf("output template is empty")
+	}
+	if strings.HasSuffix(s, "/") || strings.HasSuffix(s, string(filepath.Separator)) {
+		return nil, fmt.Errorf("output template %q must end with a file name", s)
+	}
+
+	t := &Template{raw: s}
+	rest := s
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			t.parts = append(t.parts, templatePart{text: rest})
+			break
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("output template %q has an unmatched '}'", s)
+		}
+		if open > 0 {
+			t.parts = append(t.parts, templatePart{text: rest[:open]})
+		}
+
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end < 0 || rest[open+1+end] != '}' {
+			return nil, fmt.Errorf("output template %q has an unclosed '{'", s)
+		}
+		name := rest[open+1 : open+1+end]
+		if _, ok := placeholders[name]; !ok {
+			return nil, fmt.Errorf("unknown placeholder {%s} in output template: supported are {%s}",
+				name, strings.Join(Placeholders(), "}, {"))
+		}
+		t.parts = append(t.parts, templatePart{placeholder: name})
+		rest = rest[open+1+end+1:]
+	}
+
+	return t, nil
+}
+
+// CheckRelative returns an error unless the template stays inside the
+// directory it is resolved against
+func (t *Template) CheckRelative() error {
+	if filepath.IsAbs(t.raw) || strings.HasPrefix(t.raw, "/") {
+		return fmt.Errorf("output template %q must be a relative path", t.raw)
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(t.raw), "/") {
+		if elem == ".." {
+			return fmt.Errorf("output template %q must not leave the output directory", t.raw)
+		}
+	}
+	return nil
+}
+
+// String returns the template as written
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Uses reports whether the template contains the placeholder
+func (t *Template) Uses(name string) bool {
+	for _, p := range t.parts {
+		if p.placeholder == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Execute returns the path for vars. Empty directory components, such as
+// {dir} at the input root, are dropped.
+func (t *Template) Execute(vars TemplateVars) string {
+	var sb strings.Builder
+	for _, p := range t.parts {
+		if p.placeholder != "" {
+			sb.WriteString(placeholders[p.placeholder](vars))
+		} else {
+			sb.WriteString(p.text)
+		}
+	}
+
+	path := filepath.FromSlash(sb.String())
+	if !filepath.IsAbs(t.raw) {
+		path = strings.TrimLeft(path, string(filepath.Separator))
+	}
+	return filepath.Clean(path)
+}
+
+func prefix(s string, n int) string {
+	if len(s) < n {
+		return s
+	}
+	return s[:n]
+}
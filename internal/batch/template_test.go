@@ -0,0 +1,101 @@
+package batch
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testVars() TemplateVars {
+	return TemplateVars{
+		Dir:     "a/b",
+		RelPath: "a/b/report",
+		Stem:    "report",
+		Ext:     "pdf",
+		Format:  "markdown",
+		OutExt:  "md",
+		Hash:    "0123456789abcdef",
+		Model:   "document-parse",
+		Mode:    "enhanced",
+		Date:    time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestTemplateExecute(t *testing.T) {
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"{relpath}.{outext}", "a/b/report.md"},
+		{"{yyyy}/{stem}.{model}.md", "2025/report.document-parse.md"},
+		{"{dir}/{stem}-{hash8}.{ext}.{format}", "a/b/report-01234567.pdf.markdown"},
+		{"{date}/{mm}-{dd}/{mode}/{stem}.md", "2025-03-07/03-07/enhanced/report.md"},
+		{"plain.txt", "plain.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.template)
+			require.NoError(t, err)
+			assert.Equal(t, filepath.FromSlash(tt.expected), tmpl.Execute(testVars()))
+		})
+	}
+}
+
+func TestTemplateEmptyDir(t *testing.T) {
+	tmpl, err := ParseTemplate("{dir}/{stem}.md")
+	require.NoError(t, err)
+
+	vars := testVars()
+	vars.Dir = ""
+	assert.Equal(t, "report.md", tmpl.Execute(vars))
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	tests := []struct {
+		template string
+		contains string
+	}{
+		{"", "empty"},
+		{"{stem", "unclosed"},
+		{"{st{em}.md", "unclosed"},
+		{"stem}.md", "unmatched"},
+		{"{name}.md", "unknown placeholder {name}"},
+		{"{dir}/", "file name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			_, err := ParseTemplate(tt.template)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.contains)
+		})
+	}
+}
+
+func TestTemplateCheckRelative(t *testing.T) {
+	tmpl, err := ParseTemplate("{dir}/{stem}.md")
+	require.NoError(t, err)
+	assert.NoError(t, tmpl.CheckRelative())
+
+	tmpl, err = ParseTemplate("/abs/{stem}.md")
+	require.NoError(t, err)
+	assert.ErrorContains(t, tmpl.CheckRelative(), "relative")
+	assert.Equal(t, filepath.FromSlash("/abs/report.md"), tmpl.Execute(testVars()))
+
+	tmpl, err = ParseTemplate("../{stem}.md")
+	require.NoError(t, err)
+	assert.ErrorContains(t, tmpl.CheckRelative(), "output directory")
+}
+
+func TestTemplateUses(t *testing.T) {
+	tmpl, err := ParseTemplate("{stem}-{hash8}.md")
+	require.NoError(t, err)
+
+	assert.True(t, tmpl.Uses("hash8"))
+	assert.False(t, tmpl.Uses("model"))
+	assert.Equal(t, "{stem}-{hash8}.md", tmpl.String())
+}
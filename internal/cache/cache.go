@@ -0,0 +1,250 @@
+// Package cache stores parse results on disk, keyed by document content and parse options.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/serithemage/updoc/internal/api"
+)
+
+// EnvCacheDir overrides the default cache directory
+const EnvCacheDir = "UPDOC_CACHE_DIR"
+
+// keyVersion is bumped when the key derivation or entry format changes
+const keyVersion = "v2"
+
+// entryExt is the file extension of cache entries
+const entryExt = ".json"
+
+// Cache is a directory of parse results keyed by Key
+type Cache struct {
+	dir string
+}
+
+// New creates a cache stored in dir
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultDir returns the default cache directory under the user cache dir
+func DefaultDir() (string, error) {
+	if dir := os.Getenv(EnvCacheDir); dir != "" {
+		return dir, nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	return filepath.Join(base, "updoc", "results"), nil
+}
+
+// Dir returns the cache directory
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// keyOptions are the request options that affect the parse result
+type keyOptions struct {
+	Endpoint         string   `json:"endpoint"`
+	Model            string   `json:"model"`
+	Mode             string   `json:"mode"`
+	OCR              string   `json:"ocr"`
+	ChartRecognition bool     `json:"chart_recognition"`
+	MergeTables      bool     `json:"merge_tables"`
+	Coordinates      bool     `json:"coordinates"`
+	OutputFormats    []string `json:"output_formats"`
+	Base64Categories []string `json:"base64_categories"`
+}
+
+// Key returns the cache key for a request: the SHA-256 of the document bytes
+// combined with the endpoint it is sent to and the effective parse options
+func Key(req *api.ParseRequest, endpoint string) (string, error) {
+	fileHash, err := HashFile(req.FilePath)
+	if err != nil {
+		return "", err
+	}
+
+	opts := keyOptions{
+		Endpoint:         strings.TrimRight(endpoint, "/"),
+		Model:            req.Model,
+		Mode:             req.Mode,
+		OCR:              req.OCR,
+		ChartRecognition: req.ChartRecognition,
+		MergeTables:      req.MergeTables,
+		Coordinates:      req.Coordinates,
+		OutputFormats:    sortedCopy(req.OutputFormats),
+		Base64Categories: sortedCopy(req.Base64Categories),
+	}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cache key: %w", err)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(keyVersion + "\n" + fileHash + "\n"))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashFile returns the hex SHA-256 of a file's contents
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("failed to hash file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedCopy(values []string) []string {
+	result := append([]string{}, values...)
+	sort.Strings(result)
+	return result
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+entryExt)
+}
+
+// Get returns the cached result for key. A missing or unreadable entry is a miss.
+// Reading an entry marks it as recently used for Prune.
+func (c *Cache) Get(key string) (*api.ParseResponse, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var resp api.ParseResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return &resp, true
+}
+
+// Put stores resp under key
+func (c *Cache) Put(key string, resp *api.ParseResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Stats describes the cache contents
+type Stats struct {
+	Entries int
+	Size    int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+// Stats returns the number and total size of entries
+func (c *Cache) Stats() (Stats, error) {
+	var stats Stats
+	err := c.walk(func(path string, info fs.FileInfo) error {
+		stats.Entries++
+		stats.Size += info.Size()
+		if stats.Oldest.IsZero() || info.ModTime().Before(stats.Oldest) {
+			stats.Oldest = info.ModTime()
+		}
+		if info.ModTime().After(stats.Newest) {
+			stats.Newest = info.ModTime()
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// Prune removes entries not used since before cutoff and returns how many were removed
+func (c *Cache) Prune(cutoff time.Time) (int, error) {
+	return c.remove(func(info fs.FileInfo) bool { return info.ModTime().Before(cutoff) })
+}
+
+// Clear removes every entry and returns how many were removed
+func (c *Cache) Clear() (int, error) {
+	return c.remove(func(fs.FileInfo) bool { return true })
+}
+
+// remove deletes the entries for which match returns true
+func (c *Cache) remove(match func(fs.FileInfo) bool) (int, error) {
+	removed := 0
+	err := c.walk(func(path string, info fs.FileInfo) error {
+		if !match(info) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+// walk calls fn for every cache entry. A missing cache directory has no entries.
+func (c *Cache) walk(fn func(path string, info fs.FileInfo) error) error {
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), entryExt) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(path, info)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+	return nil
+}
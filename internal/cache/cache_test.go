@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/serithemage/updoc/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDoc(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "doc.pdf")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+const testEndpoint = api.DefaultBaseURL
+
+func TestKey(t *testing.T) {
+	path := writeDoc(t, "%PDF-1.4 one")
+
+	req := api.NewParseRequest(path)
+	key, err := Key(req, testEndpoint)
+	require.NoError(t, err)
+	assert.Len(t, key, 64)
+
+	// Same content and options give the same key, regardless of path and list order
+	other := api.NewParseRequest(writeDoc(t, "%PDF-1.4 one"))
+	other.OutputFormats = []string{"text", "markdown", "html"}
+	otherKey, err := Key(other, testEndpoint)
+	require.NoError(t, err)
+	assert.Equal(t, key, otherKey)
+}
+
+func TestKeyChangesWithInput(t *testing.T) {
+	path := writeDoc(t, "%PDF-1.4 one")
+	base, err := Key(api.NewParseRequest(path), testEndpoint)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		modify func(req *api.ParseRequest)
+	}{
+		{"content", func(req *api.ParseRequest) { req.FilePath = writeDoc(t, "%PDF-1.4 two") }},
+		{"model", func(req *api.ParseRequest) { req.Model = "document-parse-nightly" }},
+		{"mode", func(req *api.ParseRequest) { req.Mode = "enhanced" }},
+		{"ocr", func(req *api.ParseRequest) { req.OCR = "force" }},
+		{"chart recognition", func(req *api.ParseRequest) { req.ChartRecognition = false }},
+		{"merge tables", func(req *api.ParseRequest) { req.MergeTables = true }},
+		{"coordinates", func(req *api.ParseRequest) { req.Coordinates = false }},
+		{"output formats", func(req *api.ParseRequest) { req.OutputFormats = []string{"html"} }},
+		{"base64 categories", func(req *api.ParseRequest) { req.Base64Categories = []string{"figure"} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := api.NewParseRequest(path)
+			tt.modify(req)
+			key, err := Key(req, testEndpoint)
+			require.NoError(t, err)
+			assert.NotEqual(t, base, key)
+		})
+	}
+}
+
+func TestKeyChangesWithEndpoint(t *testing.T) {
+	req := api.NewParseRequest(writeDoc(t, "%PDF-1.4 one"))
+	public, err := Key(req, testEndpoint)
+	require.NoError(t, err)
+	private, err := Key(req, "https://upstage.internal/v1")
+	require.NoError(t, err)
+	assert.NotEqual(t, public, private)
+
+	// A trailing slash names the same endpoint
+	slash, err := Key(req, testEndpoint+"/")
+	require.NoError(t, err)
+	assert.Equal(t, public, slash)
+}
+
+func TestKeyMissingFile(t *testing.T) {
+	_, err := Key(api.NewParseRequest("/nonexistent/file.pdf"), testEndpoint)
+	assert.Error(t, err)
+}
+
+func TestGetPut(t *testing.T) {
+	c := New(t.TempDir())
+	key, err := Key(api.NewParseRequest(writeDoc(t, "doc")), testEndpoint)
+	require.NoError(t, err)
+
+	_, ok := c.Get(key)
+	assert.False(t, ok)
+
+	resp := &api.ParseResponse{Model: "document-parse", Content: api.Content{Markdown: "# Title"}, Usage: api.Usage{Pages: 2}}
+	require.NoError(t, c.Put(key, resp))
+
+	cached, ok := c.Get(key)
+	require.True(t, ok)
+	assert.Equal(t, resp, cached)
+}
+
+func TestGetCorruptEntry(t *testing.T) {
+	c := New(t.TempDir())
+	key := "abcdef"
+	require.NoError(t, os.MkdirAll(filepath.Dir(c.path(key)), 0700))
+	require.NoError(t, os.WriteFile(c.path(key), []byte("{not json"), 0600))
+
+	_, ok := c.Get(key)
+	assert.False(t, ok)
+}
+
+func TestStatsPruneClear(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "cache"))
+
+	// A missing directory is an empty cache
+	stats, err := c.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Entries)
+
+	resp := &api.ParseResponse{Content: api.Content{Text: "text"}}
+	require.NoError(t, c.Put("aa01", resp))
+	require.NoError(t, c.Put("bb02", resp))
+	require.NoError(t, c.Put("cc03", resp))
+
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(c.path("aa01"), old, old))
+
+	stats, err = c.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Entries)
+	assert.Positive(t, stats.Size)
+	assert.WithinDuration(t, old, stats.Oldest, time.Second)
+
+	removed, err := c.Prune(time.Now().Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	_, ok := c.Get("aa01")
+	assert.False(t, ok)
+
+	removed, err = c.Clear()
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	stats, err = c.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Entries)
+}
+
+func TestDefaultDirEnvOverride(t *testing.T) {
+	t.Setenv(EnvCacheDir, "/tmp/updoc-cache")
+
+	dir, err := DefaultDir()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/updoc-cache", dir)
+}
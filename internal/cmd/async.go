@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/serithemage/updoc/internal/api"
+	"github.com/serithemage/updoc/internal/batch"
+	"github.com/serithemage/updoc/internal/jobs"
+	"github.com/spf13/cobra"
+)
+
+// runBatchAsync submits every job with the async API and polls the requests
+// concurrently, writing each result as soon as it completes. At most
+// concurrency files are uploaded at a time; polling is not limited.
+// Files with a cached result are written without being submitted.
+func runBatchAsync(cmd *cobra.Command, client *api.Client, jobs []batchJob, outputDir string, manifest *batch.Manifest,
+	concurrency int, finish batchFinishFunc) {
+	progress := newAsyncProgress(len(jobs))
+	defer progress.stop()
+
+	uploads := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths := jobs[i].paths(outputDir)
+			requestID, err := parseAsyncToFile(cmd, client, jobs[i], paths, uploads, manifest, progress, i)
+			progress.finish(i, err, func() { finish(i, paths, requestID, err) })
+		}(i)
+	}
+	wg.Wait()
+}
+
+// parseAsyncToFile parses a batch job's file with the async API and writes the
+// result to paths. A slot in uploads is held until the file is submitted.
+// It returns the request ID, or "" if the result came from the cache.
+func parseAsyncToFile(cmd *cobra.Command, client *api.Client, job batchJob, paths []string, uploads chan struct{},
+	manifest *batch.Manifest, progress *asyncProgress, i int) (string, error) {
+	ctx := cmd.Context()
+	req := buildParseRequest(cmd, job.file)
+
+	select {
+	case uploads <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	var release sync.Once
+	releaseUpload := func() { release.Do(func() { <-uploads }) }
+	defer releaseUpload()
+
+	var requestID string
+	resp, err := cachedParse(cmd, req, func() (*api.ParseResponse, error) {
+		progress.set(i, asyncUploading)
+		submitted, err := client.ParseAsync(ctx, req)
+		if err != nil {
+			releaseUpload()
+			return nil, err
+		}
+		progress.set(i, asyncRunning)
+		releaseUpload()
+		requestID = submitted.RequestID
+
+		// Record the request so its result can still be fetched if updoc exits early
+		manifest.Set(batch.Entry{Source: absPath(job.file), RequestID: requestID, Status: batch.StatusPending})
+		saveManifest(manifest)
+		record := jobs.New(requestID, req, GetEndpoint(cmd))
+		recordJob(record)
+
+		resp, err := client.WaitForCompletion(ctx, requestID, api.WaitOptions{
+			InitialDelay: api.DefaultPollInterval,
+			OnStatus: func(status *api.StatusResponse) {
+				progress.pages(i, status.PagesProcessed, status.TotalPages)
+				record.Update(status)
+			},
+		})
+		recordJob(record)
+		return resp, asyncError(err)
+	})
+	if err != nil {
+		return requestID, err
+	}
+
+	return requestID, writeResultFile(cmd, resp, job.name(), paths)
+}
+
+// asyncError maps a failed async request to ExitAPI
+func asyncError(err error) error {
+	var failed *api.RequestFailedError
+	if errors.As(err, &failed) {
+		return &ExitError{Code: ExitAPI, Err: failed}
+	}
+	return err
+}
+
+// asyncState is the stage of one file in an async batch
+type asyncState int
+
+const (
+	asyncQueued asyncState = iota
+	asyncUploading
+	asyncRunning
+	asyncDone
+	asyncFailed
+)
+
+// asyncProgress tracks the files of an async batch and renders their aggregate
+// state as a single line on stderr. Nothing is rendered in quiet mode or when
+// stderr is not a terminal.
+type asyncProgress struct {
+	mu         sync.Mutex
+	live       bool
+	start      time.Time
+	states     []asyncState
+	pagesDone  []int
+	pagesTotal []int
+}
+
+func newAsyncProgress(n int) *asyncProgress {
+	return &asyncProgress{
+		live:       !quiet && isTerminal(os.Stderr),
+		start:      time.Now(),
+		states:     make([]asyncState, n),
+		pagesDone:  make([]int, n),
+		pagesTotal: make([]int, n),
+	}
+}
+
+// set moves file i to state
+func (p *asyncProgress) set(i int, state asyncState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.states[i] = state
+	p.render()
+}
+
+// pages records the pages processed so far for file i
+func (p *asyncProgress) pages(i, done, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pagesDone[i], p.pagesTotal[i] = done, total
+	p.render()
+}
+
+// finish marks file i done or failed and runs report, which may print,
+// with the progress line cleared
+func (p *asyncProgress) finish(i int, err error, report func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.states[i] = asyncDone
+	if err != nil {
+		p.states[i] = asyncFailed
+	} else if p.pagesTotal[i] > 0 {
+		p.pagesDone[i] = p.pagesTotal[i]
+	}
+
+	p.clear()
+	report()
+	p.render()
+}
+
+// stop clears the progress line
+func (p *asyncProgress) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	p.live = false
+}
+
+func (p *asyncProgress) clear() {
+	if p.live {
+		fmt.Fprint(os.Stderr, "\033[2K\r")
+	}
+}
+
+func (p *asyncProgress) render() {
+	if !p.live {
+		return
+	}
+
+	var counts [asyncFailed + 1]int
+	var pagesDone, pagesTotal int
+	for i, state := range p.states {
+		counts[state]++
+		pagesDone += p.pagesDone[i]
+		pagesTotal += p.pagesTotal[i]
+	}
+
+	parts := []string{
+		fmt.Sprintf("Done %d/%d", counts[asyncDone], len(p.states)),
+		fmt.Sprintf("Running %d", counts[asyncRunning]),
+		fmt.Sprintf("Uploading %d", counts[asyncUploading]),
+		fmt.Sprintf("Queued %d", counts[asyncQueued]),
+	}
+	if counts[asyncFailed] > 0 {
+		parts = append(parts, fmt.Sprintf("Failed %d", counts[asyncFailed]))
+	}
+	if pagesTotal > 0 {
+		parts = append(parts, fmt.Sprintf("Pages %d/%d", pagesDone, pagesTotal))
+	}
+
+	fmt.Fprintf(os.Stderr, "\033[2K\r[%s] %s", time.Since(p.start).Round(time.Second), strings.Join(parts, " | "))
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/serithemage/updoc/internal/api"
+	"github.com/serithemage/updoc/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local result cache",
+	Long: `Manage the local cache of parse results.
+
+Results are cached by the SHA-256 of the document and the parse options
+(model, mode, OCR, chart recognition, table merging, coordinates, output
+formats and base64 categories). Parsing an unchanged document with the same
+options reuses the cached result without calling the API.
+
+The cache is stored under the user cache directory, or in $UPDOC_CACHE_DIR.`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache size and location",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := openCache()
+		if err != nil {
+			return err
+		}
+
+		stats, err := c.Stats()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Directory: %s\n", c.Dir())
+		fmt.Printf("Entries:   %d\n", stats.Entries)
+		fmt.Printf("Size:      %s\n", formatBytes(stats.Size))
+		if stats.Entries > 0 {
+			fmt.Printf("Oldest:    %s\n", stats.Oldest.Format(time.RFC3339))
+			fmt.Printf("Newest:    %s\n", stats.Newest.Format(time.RFC3339))
+		}
+		return nil
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached results not used recently",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value, _ := cmd.Flags().GetString("older-than")
+		age, err := parseAge(value)
+		if err != nil {
+			return &ExitError{Code: ExitUsage, Err: fmt.Errorf("invalid --older-than: %w", err)}
+		}
+
+		c, err := openCache()
+		if err != nil {
+			return err
+		}
+
+		removed, err := c.Prune(time.Now().Add(-age))
+		if err != nil {
+			return err
+		}
+		Printf("Removed %d cached results not used in %s\n", removed, value)
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached results",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := openCache()
+		if err != nil {
+			return err
+		}
+
+		removed, err := c.Clear()
+		if err != nil {
+			return err
+		}
+		Printf("Removed %d cached results\n", removed)
+		return nil
+	},
+}
+
+func init() {
+	cachePruneCmd.Flags().String("older-than", "30d", "remove results not used within this age, e.g. 12h, 30d")
+
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// addCacheFlags registers the flags that control result caching
+func addCacheFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-cache", false, "do not read or write the local result cache")
+	cmd.Flags().Bool("refresh", false, "parse again and replace the cached result")
+}
+
+func openCache() (*cache.Cache, error) {
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return cache.New(dir), nil
+}
+
+// parseDocument parses req with the API, using the local result cache unless
+// --no-cache is set. With --refresh the cached result is replaced.
+func parseDocument(ctx context.Context, cmd *cobra.Command, client *api.Client, req *api.ParseRequest) (*api.ParseResponse, error) {
+	return cachedParse(cmd, req, func() (*api.ParseResponse, error) {
+		return client.Parse(ctx, req)
+	})
+}
+
+// cachedParse returns the cached result for req, or calls parse and caches its result.
+// The cache is read unless --refresh is set, written on every successful parse,
+// and bypassed entirely with --no-cache.
+func cachedParse(cmd *cobra.Command, req *api.ParseRequest, parse func() (*api.ParseResponse, error)) (*api.ParseResponse, error) {
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); noCache {
+		return parse()
+	}
+
+	c, err := openCache()
+	if err != nil {
+		Verbosef("Cache disabled: %v\n", err)
+		return parse()
+	}
+	key, err := cache.Key(req, GetEndpoint(cmd))
+	if err != nil {
+		return nil, err
+	}
+
+	if refresh, _ := cmd.Flags().GetBool("refresh"); !refresh {
+		if resp, ok := c.Get(key); ok {
+			Verbosef("Using cached result for %s\n", req.FilePath)
+			return resp, nil
+		}
+	}
+
+	resp, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Put(key, resp); err != nil {
+		Verbosef("Failed to cache result: %v\n", err)
+	}
+	return resp, nil
+}
+
+// parseAge parses a duration such as 90m, 12h or 30d
+func parseAge(value string) (time.Duration, error) {
+	var age time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a duration", value)
+		}
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a duration", value)
+		}
+		age = d
+	}
+
+	if age < 0 {
+		return 0, fmt.Errorf("%q is negative", value)
+	}
+	return age, nil
+}
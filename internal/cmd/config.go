@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/serithemage/updoc/internal/config"
@@ -13,33 +14,58 @@ import (
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Manage configuration",
-	Long:  `Manage updoc configuration settings.`,
+	Long: `Manage updoc configuration settings.
+
+Settings are read from and written to the selected profile (see
+updoc config profile).`,
 }
 
 var configSetCmd = &cobra.Command{
 	Use:   "set <key> <value>",
 	Short: "Set a configuration value",
-	Args:  cobra.ExactArgs(2),
+	Long: `Set a configuration value in the selected profile.
+
+With --encrypt, the API key is stored encrypted with a passphrase, read from
+$UPDOC_PASSPHRASE or entered on the terminal. Commands that need the key then
+ask for the passphrase, or read it from $UPDOC_PASSPHRASE.`,
+	Example: `  updoc config set default-format html
+  updoc config set api-key up_xxx --encrypt`,
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		key := args[0]
 		value := args[1]
-
-		cfg := GetConfig()
-
-		if err := cfg.Set(key, value); err != nil {
-			return err
+		encrypt, _ := cmd.Flags().GetBool("encrypt")
+		if encrypt && key != "api-key" {
+			return newExitError(ExitUsage, "--encrypt can only be used with api-key")
 		}
 
-		configPath := config.GetDefaultConfigPath()
-		if cfgFile != "" {
-			configPath = cfgFile
+		stored, err := storedProfile()
+		if err != nil {
+			return &ExitError{Code: ExitUsage, Err: err}
 		}
+		if encrypt {
+			passphrase, err := readPassphrase("New passphrase: ", true)
+			if err != nil {
+				return &ExitError{Code: ExitUsage, Err: err}
+			}
+			if err := stored.SetEncryptedAPIKey(value, passphrase); err != nil {
+				return &ExitError{Code: ExitUsage, Err: err}
+			}
+			value = config.MaskAPIKey(value) + " (encrypted)"
+		} else if err := stored.Set(key, value); err != nil {
+			return &ExitError{Code: ExitUsage, Err: err}
+		}
+
+		configPath := configFilePath()
 
-		if err := cfg.SaveTo(configPath); err != nil {
+		if err := configFile().SaveTo(configPath); err != nil {
 			return fmt.Errorf("failed to save config: %w", err)
 		}
 
 		Printf("Set %s = %s\n", key, value)
+		if origin := settingOrigin(key); strings.HasPrefix(origin, "project") || strings.HasPrefix(origin, "env") {
+			fmt.Fprintf(os.Stderr, "Warning: %s is overridden by %s\n", key, origin)
+		}
 		return nil
 	},
 }
@@ -54,11 +80,13 @@ var configGetCmd = &cobra.Command{
 		cfg := GetConfig()
 		value, err := cfg.Get(key)
 		if err != nil {
-			return err
+			return &ExitError{Code: ExitUsage, Err: err}
 		}
 
 		// Mask API key for security
-		if key == "api-key" && value != "" {
+		if key == "api-key" && cfg.IsAPIKeyLocked() {
+			fmt.Println("(encrypted)")
+		} else if key == "api-key" && value != "" {
 			fmt.Println(config.MaskAPIKey(value))
 		} else {
 			fmt.Println(value)
@@ -74,42 +102,76 @@ var configListCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg := GetConfig()
 
-		fmt.Println("Configuration:")
+		fmt.Printf("Configuration (profile: %s):\n", profileName)
 		fmt.Println()
 
 		// API Key (masked)
 		apiKeyDisplay := "(not set)"
-		if cfg.APIKey != "" {
+		switch {
+		case cfg.IsAPIKeyLocked():
+			apiKeyDisplay = "(encrypted)"
+		case cfg.APIKey != "":
 			apiKeyDisplay = config.MaskAPIKey(cfg.APIKey) + " (set)"
 		}
-		fmt.Printf("  api-key:        %s\n", apiKeyDisplay)
-		fmt.Printf("  default-format: %s\n", cfg.DefaultFormat)
-		fmt.Printf("  default-mode:   %s\n", cfg.DefaultMode)
-		fmt.Printf("  default-ocr:    %s\n", cfg.DefaultOCR)
-
+		apiKeyCommand := cfg.APIKeyCommand
+		if apiKeyCommand == "" {
+			apiKeyCommand = "(not set)"
+		}
 		outputDir := cfg.OutputDir
 		if outputDir == "" {
 			outputDir = "(not set)"
 		}
-		fmt.Printf("  output-dir:     %s\n", outputDir)
-		fmt.Println()
 
-		configPath := config.GetDefaultConfigPath()
-		if cfgFile != "" {
-			configPath = cfgFile
+		settings := []struct{ key, value string }{
+			{"api-key", apiKeyDisplay},
+			{"api-key-command", apiKeyCommand},
+			{"endpoint", cfg.GetEndpoint()},
+			{"default-format", cfg.DefaultFormat},
+			{"default-mode", cfg.DefaultMode},
+			{"default-ocr", cfg.DefaultOCR},
+			{"output-dir", outputDir},
+			{"merge-tables", strconv.FormatBool(cfg.MergeTables)},
+			{"retries", strconv.Itoa(cfg.Retries)},
+			{"retry-max-wait", fmt.Sprintf("%ds", cfg.RetryMaxWait)},
+			{"concurrency", strconv.Itoa(cfg.Concurrency)},
+			{"rate-limit", formatLimit(strconv.FormatFloat(cfg.RateLimit, 'f', -1, 64), "requests/sec")},
+			{"pages-per-minute", formatLimit(strconv.Itoa(cfg.PagesPerMinute), "pages/min")},
 		}
+
+		showOrigin, _ := cmd.Flags().GetBool("show-origin")
+		for _, s := range settings {
+			if showOrigin {
+				fmt.Printf("  %-18s%-32s %s\n", s.key+":", s.value, settingOrigin(s.key))
+			} else {
+				fmt.Printf("  %-18s%s\n", s.key+":", s.value)
+			}
+		}
+		fmt.Println()
+
+		configPath := configFilePath()
 		fmt.Printf("Config file: %s\n", configPath)
+		if path := config.FindProjectFile("."); path != "" {
+			fmt.Printf("Project file: %s\n", path)
+		}
 	},
 }
 
 var configResetCmd = &cobra.Command{
 	Use:   "reset",
-	Short: "Reset configuration to defaults",
+	Short: "Reset the selected profile to defaults",
+	Long: `Reset the settings of the selected profile to their defaults. Other
+profiles are kept. With --all, the config file is deleted, removing every
+profile.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		force, _ := cmd.Flags().GetBool("force")
+		all, _ := cmd.Flags().GetBool("all")
 
 		if !force {
-			fmt.Print("Are you sure you want to reset all configuration? [y/N] ")
+			if all {
+				fmt.Print("Are you sure you want to delete all configuration, including every profile? [y/N] ")
+			} else {
+				fmt.Printf("Are you sure you want to reset the settings of profile %s? [y/N] ", profileName)
+			}
 			reader := bufio.NewReader(os.Stdin)
 			response, _ := reader.ReadString('\n')
 			response = strings.TrimSpace(strings.ToLower(response))
@@ -119,9 +181,19 @@ var configResetCmd = &cobra.Command{
 			}
 		}
 
-		configPath := config.GetDefaultConfigPath()
-		if cfgFile != "" {
-			configPath = cfgFile
+		configPath := configFilePath()
+
+		if !all {
+			stored, err := storedProfile()
+			if err != nil {
+				return &ExitError{Code: ExitUsage, Err: err}
+			}
+			stored.ResetSettings()
+			if err := configFile().SaveTo(configPath); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			Printf("Profile %s reset to defaults.\n", profileName)
+			return nil
 		}
 
 		// Remove config file if exists
@@ -130,7 +202,9 @@ var configResetCmd = &cobra.Command{
 		}
 
 		// Reset in-memory config
-		cfg = config.New()
+		cfg = nil
+		profile = nil
+		GetConfig()
 
 		Printf("Configuration reset to defaults.\n")
 		return nil
@@ -141,16 +215,24 @@ var configPathCmd = &cobra.Command{
 	Use:   "path",
 	Short: "Show configuration file path",
 	Run: func(cmd *cobra.Command, args []string) {
-		configPath := config.GetDefaultConfigPath()
-		if cfgFile != "" {
-			configPath = cfgFile
-		}
+		configPath := configFilePath()
 		fmt.Println(configPath)
 	},
 }
 
+// formatLimit formats a throttling setting where 0 means unlimited
+func formatLimit(value, unit string) string {
+	if value == "0" {
+		return "unlimited"
+	}
+	return value + " " + unit
+}
+
 func init() {
+	configSetCmd.Flags().Bool("encrypt", false, "store the API key encrypted with a passphrase")
 	configResetCmd.Flags().Bool("force", false, "skip confirmation prompt")
+	configResetCmd.Flags().Bool("all", false, "delete the config file, including every profile")
+	configListCmd.Flags().Bool("show-origin", false, "show where each value comes from")
 
 	configCmd.AddCommand(configSetCmd)
 	configCmd.AddCommand(configGetCmd)
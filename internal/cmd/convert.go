@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/serithemage/updoc/internal/api"
+	"github.com/serithemage/updoc/internal/batch"
+	"github.com/serithemage/updoc/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert <result.json|directory|pattern>",
+	Short: "Convert saved JSON results to another output format",
+	Long: `Convert parse results saved with --json to another output format without
+calling the API again.
+
+The result must contain the content format being converted to: a result
+parsed with --output-formats html cannot be converted to markdown.
+
+Examples:
+  # Convert a saved result to markdown
+  updoc convert result.json -f markdown -o result.md
+
+  # Split a saved result into chunks
+  updoc convert result.json -f chunks --chunk-size 512 --chunk-unit tokens
+
+  # List the elements of a saved result
+  updoc convert result.json --elements-only -f text
+
+  # Convert a directory of results
+  updoc convert ./results/ -f text --output-dir ./text/`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConvert,
+}
+
+func init() {
+	convertCmd.Flags().StringP("format", "f", "", "output format: html, markdown, text, json, chunks, or a comma-separated list (default from config or markdown)")
+	convertCmd.Flags().StringP("output", "o", "", "output file path (default: stdout)")
+	convertCmd.Flags().StringP("output-dir", "d", "", "output directory for batch conversion")
+	convertCmd.Flags().BoolP("recursive", "r", false, "process directories recursively")
+	addLayoutFlags(convertCmd)
+	convertCmd.Flags().BoolP("elements-only", "e", false, "output only elements")
+	convertCmd.Flags().String("extract-images", "", "directory to save element images to; figures are linked from markdown/html output")
+	convertCmd.Flags().String("tables-dir", "", "directory to export table elements to as spreadsheet files")
+	convertCmd.Flags().String("tables-format", output.TableFormatCSV, "table export format: csv, tsv, xlsx")
+	convertCmd.Flags().Int("chunk-size", output.DefaultChunkSize, "maximum chunk size for --format chunks")
+	convertCmd.Flags().Int("chunk-overlap", output.DefaultChunkOverlap, "content shared between consecutive chunks for --format chunks")
+	convertCmd.Flags().String("chunk-unit", output.ChunkUnitChars, "chunk size unit: chars, tokens (approximate)")
+
+	rootCmd.AddCommand(convertCmd)
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	inputPath := args[0]
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+	recursive, _ := cmd.Flags().GetBool("recursive")
+
+	outputPath, _ := cmd.Flags().GetString("output")
+	if err := validateOutputOptions(cmd, outputPath == "" && outputDir == ""); err != nil {
+		return err
+	}
+
+	files, err := collectMatchingFiles(inputPath, recursive, isJSONFile)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return newExitError(ExitUsage, "no JSON results found matching: %s", inputPath)
+	}
+
+	if len(files) == 1 && outputDir == "" {
+		resp, err := loadParseResponse(files[0])
+		if err != nil {
+			return err
+		}
+		return outputResult(cmd, resp, fileStem(files[0]))
+	}
+
+	if outputDir == "" {
+		return newExitError(ExitUsage, "--output-dir is required for batch conversion (multiple files)")
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	jobs, err := planBatchJobs(cmd, files, inputRoot(inputPath), getFormats(cmd))
+	if err != nil {
+		return err
+	}
+
+	return convertBatch(cmd, jobs, outputDir)
+}
+
+// convertBatch converts each result file into outputDir
+func convertBatch(cmd *cobra.Command, jobs []batchJob, outputDir string) error {
+	Printf("Converting %d files...\n\n", len(jobs))
+
+	var failCount int
+	var firstErr error
+	for _, job := range jobs {
+		filePath := job.file
+		paths := job.paths(outputDir)
+
+		err := convertToFile(cmd, job, paths)
+		if err != nil {
+			failCount++
+			if firstErr == nil {
+				firstErr = err
+			}
+			Printf("Converting: %s... failed (%v)\n", filepath.Base(filePath), err)
+			continue
+		}
+		Printf("Converting: %s... done -> %s\n", filepath.Base(filePath), strings.Join(paths, ", "))
+	}
+
+	Printf("\nSummary:\n")
+	Printf("  Total:   %d\n", len(jobs))
+	Printf("  Success: %d\n", len(jobs)-failCount)
+	Printf("  Failed:  %d\n", failCount)
+
+	if failCount > 0 {
+		return batchError(failCount, len(jobs), firstErr)
+	}
+	return nil
+}
+
+func convertToFile(cmd *cobra.Command, job batchJob, paths []string) error {
+	resp, err := loadParseResponse(job.file)
+	if err != nil {
+		return err
+	}
+	return writeResultFile(cmd, resp, job.name(), paths)
+}
+
+// isJSONFile reports whether path may be a saved parse result. The batch
+// manifest written next to the results is not one.
+func isJSONFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json") && filepath.Base(path) != batch.ManifestName
+}
+
+// loadParseResponse reads a parse result saved with --json
+func loadParseResponse(path string) (*api.ParseResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &ExitError{Code: ExitFileIO, Err: fmt.Errorf("failed to read result: %w", err)}
+	}
+
+	var resp api.ParseResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, newExitError(ExitUsage, "%s is not a parse result: %v", path, err)
+	}
+	if len(resp.Elements) == 0 && resp.Content == (api.Content{}) {
+		return nil, newExitError(ExitUsage, "%s is not a parse result: no content or elements", path)
+	}
+	return &resp, nil
+}
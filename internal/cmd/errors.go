@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/serithemage/updoc/internal/api"
+)
+
+// Exit codes (see PRD 4.2)
+const (
+	ExitOK             = 0
+	ExitGeneral        = 1   // general error
+	ExitUsage          = 2   // invalid arguments or flags
+	ExitAPI            = 3   // API request failed
+	ExitFileIO         = 4   // reading input or writing output failed
+	ExitAuth           = 5   // missing or rejected API key
+	ExitPartialFailure = 6   // some files in a batch failed
+	ExitTimeout        = 7   // waiting for an async request timed out
+	ExitInterrupted    = 130 // interrupted by SIGINT or SIGTERM
+)
+
+// ExitError is an error with an explicit process exit code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// newExitError creates an ExitError with a formatted message
+func newExitError(code int, format string, a ...interface{}) error {
+	return &ExitError{Code: code, Err: fmt.Errorf(format, a...)}
+}
+
+// errAPIKeyNotSet is returned when no API key is configured
+var errAPIKeyNotSet = &ExitError{
+	Code: ExitAuth,
+	Err:  errors.New("API key not set. Set it with 'updoc config set api-key <your-key>' or UPSTAGE_API_KEY environment variable"),
+}
+
+// ExitCode maps an error returned by Execute to a process exit code
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	if errors.Is(err, errInterrupted) {
+		return ExitInterrupted
+	}
+
+	if errors.Is(err, api.ErrAuthentication) {
+		return ExitAuth
+	}
+
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) || errors.Is(err, api.ErrNetwork) {
+		return ExitAPI
+	}
+
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return ExitFileIO
+	}
+
+	return ExitGeneral
+}
+
+// batchError summarizes failed files in a batch run. When every file failed the
+// exit code follows the first failure, otherwise it reports a partial failure.
+func batchError(failed, total int, firstErr error) error {
+	code := ExitPartialFailure
+	if failed == total {
+		code = ExitCode(firstErr)
+	}
+	return newExitError(code, "%d files failed to process", failed)
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/serithemage/updoc/internal/api"
+	"github.com/serithemage/updoc/internal/jobs"
+	"github.com/spf13/cobra"
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "List and manage submitted async requests",
+	Long: `List and manage async parse requests submitted with --async.
+
+Every submitted request is recorded with its source file, parse options,
+endpoint and last known status, so it can be found again after the terminal
+scrolls. The records are stored under the user cache directory, or in
+$UPDOC_JOBS_DIR.
+
+Wherever a request ID is expected, "latest" refers to the most recently
+submitted request and a file path to the latest request for that file.`,
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded async requests, newest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openJobs()
+		if err != nil {
+			return err
+		}
+		list, err := store.List()
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			if list == nil {
+				list = []*jobs.Job{}
+			}
+			data, _ := json.MarshalIndent(list, "", "  ")
+			fmt.Println(string(data))
+			return nil
+		}
+
+		if len(list) == 0 {
+			fmt.Println("No async requests recorded")
+			return nil
+		}
+		fmt.Printf("%-36s  %-10s  %-16s  %s\n", "REQUEST ID", "STATUS", "SUBMITTED", "SOURCE")
+		for _, job := range list {
+			fmt.Printf("%-36s  %-10s  %-16s  %s\n", job.RequestID, job.Status,
+				job.SubmittedAt.Local().Format("2006-01-02 15:04"), job.Source)
+		}
+		return nil
+	},
+}
+
+var jobsShowCmd = &cobra.Command{
+	Use:   "show <request-id|latest|file>",
+	Short: "Show a recorded async request",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		requestID, err := resolveRequestID(args[0])
+		if err != nil {
+			return err
+		}
+		job := lookupJob(requestID)
+		if job == nil {
+			return newExitError(ExitUsage, "no async request %s recorded", requestID)
+		}
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			data, _ := json.MarshalIndent(job, "", "  ")
+			fmt.Println(string(data))
+			return nil
+		}
+
+		printJob(job)
+		return nil
+	},
+}
+
+var jobsRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Update the status of unfinished async requests from the API",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiKey, err := GetAPIKey(cmd)
+		if err != nil {
+			return err
+		}
+
+		store, err := openJobs()
+		if err != nil {
+			return err
+		}
+		list, err := store.List()
+		if err != nil {
+			return err
+		}
+
+		var refreshed, failed int
+		for _, job := range list {
+			if job.Finished() {
+				continue
+			}
+			status, err := newJobClient(cmd, apiKey, job.RequestID).GetStatus(cmd.Context(), job.RequestID)
+			if err != nil {
+				if cmd.Context().Err() != nil {
+					return err
+				}
+				failed++
+				fmt.Fprintf(os.Stderr, "Warning: failed to get status of %s: %v\n", job.RequestID, err)
+				continue
+			}
+			job.Update(status)
+			if err := store.Save(job); err != nil {
+				return err
+			}
+			refreshed++
+			Printf("%-36s  %s\n", job.RequestID, job.Status)
+		}
+
+		Printf("Refreshed %d unfinished requests\n", refreshed)
+		if failed > 0 {
+			return newExitError(ExitAPI, "failed to refresh %d requests", failed)
+		}
+		return nil
+	},
+}
+
+var jobsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove records of requests submitted long ago",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value, _ := cmd.Flags().GetString("older-than")
+		age, err := parseAge(value)
+		if err != nil {
+			return &ExitError{Code: ExitUsage, Err: fmt.Errorf("invalid --older-than: %w", err)}
+		}
+
+		store, err := openJobs()
+		if err != nil {
+			return err
+		}
+		removed, err := store.Prune(time.Now().Add(-age))
+		if err != nil {
+			return err
+		}
+		Printf("Removed %d requests submitted more than %s ago\n", removed, value)
+		return nil
+	},
+}
+
+func init() {
+	jobsListCmd.Flags().BoolP("json", "j", false, "output as JSON")
+	jobsShowCmd.Flags().BoolP("json", "j", false, "output as JSON")
+	jobsPruneCmd.Flags().String("older-than", "30d", "remove requests submitted before this age, e.g. 12h, 30d")
+
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsCmd.AddCommand(jobsShowCmd)
+	jobsCmd.AddCommand(jobsRefreshCmd)
+	jobsCmd.AddCommand(jobsPruneCmd)
+
+	rootCmd.AddCommand(jobsCmd)
+}
+
+func openJobs() (*jobs.Store, error) {
+	dir, err := jobs.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return jobs.NewStore(dir), nil
+}
+
+// recordJob saves job to the registry, warning instead of failing on error
+func recordJob(job *jobs.Job) {
+	store, err := openJobs()
+	if err == nil {
+		err = store.Save(job)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record async request: %v\n", err)
+	}
+}
+
+// lookupJob returns the recorded job for requestID, or nil if there is none
+func lookupJob(requestID string) *jobs.Job {
+	store, err := openJobs()
+	if err != nil {
+		return nil
+	}
+	job, err := store.Get(requestID)
+	if err != nil {
+		return nil
+	}
+	return job
+}
+
+// updateJob records a status received for requestID if the request is recorded
+func updateJob(requestID string, status *api.StatusResponse) {
+	if job := lookupJob(requestID); job != nil {
+		job.Update(status)
+		recordJob(job)
+	}
+}
+
+// resolveRequestID turns "latest", a file path or a request ID into a request ID
+func resolveRequestID(ref string) (string, error) {
+	store, err := openJobs()
+	if err != nil {
+		return "", err
+	}
+
+	var job *jobs.Job
+	switch info, statErr := os.Stat(ref); {
+	case ref == "latest":
+		job, err = store.Latest()
+		if errors.Is(err, jobs.ErrNotFound) {
+			return "", newExitError(ExitUsage, "no async requests recorded")
+		}
+	case statErr == nil && !info.IsDir():
+		job, err = store.FindBySource(ref)
+		if errors.Is(err, jobs.ErrNotFound) {
+			return "", newExitError(ExitUsage, "no async request recorded for %s", ref)
+		}
+	default:
+		return ref, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	Verbosef("Using request %s (%s)\n", job.RequestID, job.Source)
+	return job.RequestID, nil
+}
+
+// newJobClient creates an API client for the endpoint requestID was submitted
+// to. An explicit --endpoint takes precedence.
+func newJobClient(cmd *cobra.Command, apiKey, requestID string) *api.Client {
+	if job := lookupJob(requestID); job != nil && job.Endpoint != "" && !cmd.Flags().Changed("endpoint") {
+		return newAPIClientFor(cmd, apiKey, job.Endpoint)
+	}
+	return NewAPIClient(cmd, apiKey)
+}
+
+func printJob(job *jobs.Job) {
+	fmt.Printf("Request ID: %s\n", job.RequestID)
+	fmt.Printf("Source:     %s\n", job.Source)
+	fmt.Printf("Endpoint:   %s\n", job.Endpoint)
+	fmt.Printf("Submitted:  %s\n", job.SubmittedAt.Local().Format(time.RFC3339))
+	fmt.Printf("Status:     %s\n", job.Status)
+	if job.TotalPages > 0 {
+		fmt.Printf("Pages:      %d/%d\n", job.PagesProcessed, job.TotalPages)
+	}
+	if job.Error != "" {
+		fmt.Printf("Error:      %s\n", job.Error)
+	}
+	if !job.CheckedAt.IsZero() {
+		fmt.Printf("Checked:    %s\n", job.CheckedAt.Local().Format(time.RFC3339))
+	}
+	fmt.Printf("Options:    model=%s mode=%s ocr=%s\n", job.Options.Model, job.Options.Mode, job.Options.OCR)
+}
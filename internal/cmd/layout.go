@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/serithemage/updoc/internal/api"
+	"github.com/serithemage/updoc/internal/batch"
+	"github.com/serithemage/updoc/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+// Collision handling modes for batch output names
+const (
+	collisionSuffix = "suffix"
+	collisionError  = "error"
+)
+
+// batchJob is a file processed in batch mode and where its output is written
+type batchJob struct {
+	file    string
+	outputs []string // output path per output format, relative to the output directory
+}
+
+// name returns the first output path without its extension. Images and tables
+// extracted from the document are written to directories with this name.
+func (j batchJob) name() string {
+	return trimExt(j.outputs[0])
+}
+
+// paths returns the output paths inside dir
+func (j batchJob) paths(dir string) []string {
+	paths := make([]string, len(j.outputs))
+	for i, output := range j.outputs {
+		paths[i] = filepath.Join(dir, output)
+	}
+	return paths
+}
+
+// rename replaces each output path with rename(path without extension, extension)
+func (j *batchJob) rename(rename func(base, ext string) string) {
+	for i, output := range j.outputs {
+		j.outputs[i] = rename(trimExt(output), filepath.Ext(output))
+	}
+}
+
+// addLayoutFlags registers the flags that control batch output paths
+func addLayoutFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("flat", false, "write all batch output into the output directory instead of mirroring the input tree")
+	cmd.Flags().String("on-collision", collisionSuffix, "when two inputs map to the same output name: suffix (add the source extension) or error")
+}
+
+// planBatchJobs assigns each file an output path per format. With
+// --output-template the template decides; otherwise the output tree mirrors
+// the input tree relative to root, or with --flat only the file stem is used.
+// Files mapping to the same path are disambiguated or rejected per --on-collision.
+func planBatchJobs(cmd *cobra.Command, files []string, root string, formats []string) ([]batchJob, error) {
+	flat, _ := cmd.Flags().GetBool("flat")
+	onCollision, _ := cmd.Flags().GetString("on-collision")
+	if onCollision != collisionSuffix && onCollision != collisionError {
+		return nil, newExitError(ExitUsage, "invalid --on-collision %q: must be suffix or error", onCollision)
+	}
+
+	tmpl, err := getOutputTemplate(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl != nil {
+		if err := tmpl.CheckRelative(); err != nil {
+			return nil, &ExitError{Code: ExitUsage, Err: err}
+		}
+	}
+
+	now := time.Now()
+	jobs := make([]batchJob, len(files))
+	for i, file := range files {
+		if tmpl != nil {
+			vars, err := templateVars(cmd, tmpl, file, root, now)
+			if err != nil {
+				return nil, err
+			}
+			jobs[i] = batchJob{file: file, outputs: templateOutputs(tmpl, vars, formats)}
+			continue
+		}
+
+		name := fileStem(file)
+		if !flat {
+			if rel, ok := insideRoot(root, file); ok {
+				name = filepath.Join(filepath.Dir(rel), name)
+			}
+		}
+		jobs[i] = batchJob{file: file, outputs: formatOutputs(name, formats)}
+	}
+
+	if err := resolveCollisions(jobs, onCollision); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// resolveCollisions makes output names unique. Names are compared case-insensitively
+// because macOS and Windows file systems usually are.
+func resolveCollisions(jobs []batchJob, onCollision string) error {
+	groups := make(map[string][]int)
+	var order []string
+	for i, job := range jobs {
+		key := strings.ToLower(job.outputs[0])
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	var conflicts []string
+	for _, key := range order {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		if onCollision == collisionError {
+			var sources []string
+			for _, i := range group {
+				sources = append(sources, jobs[i].file)
+			}
+			conflicts = append(conflicts, fmt.Sprintf("  %s <- %s", jobs[group[0]].outputs[0], strings.Join(sources, ", ")))
+			continue
+		}
+		// Keep the source extension so report.pdf and report.docx stay apart
+		for _, i := range group {
+			sourceExt := filepath.Ext(jobs[i].file)
+			jobs[i].rename(func(base, ext string) string { return base + sourceExt + ext })
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return newExitError(ExitUsage, "multiple inputs map to the same output name (use --on-collision suffix, or drop --flat):\n%s",
+			strings.Join(conflicts, "\n"))
+	}
+
+	// Number any names that still collide, e.g. a/report.pdf and b/report.pdf with --flat
+	used := make(map[string]bool)
+	for i := range jobs {
+		base, ext := jobs[i].name(), filepath.Ext(jobs[i].outputs[0])
+		n := 1
+		for used[strings.ToLower(numbered(base, n)+ext)] {
+			n++
+		}
+		if n > 1 {
+			jobs[i].rename(func(base, ext string) string { return numbered(base, n) + ext })
+		}
+		used[strings.ToLower(jobs[i].outputs[0])] = true
+	}
+	return nil
+}
+
+// numbered returns base with a "-n" suffix for n > 1
+func numbered(base string, n int) string {
+	if n < 2 {
+		return base
+	}
+	return fmt.Sprintf("%s-%d", base, n)
+}
+
+// getOutputTemplate returns the parsed --output-template, or nil if it is not set
+func getOutputTemplate(cmd *cobra.Command) (*batch.Template, error) {
+	value, _ := cmd.Flags().GetString("output-template")
+	if value == "" {
+		return nil, nil
+	}
+	tmpl, err := batch.ParseTemplate(value)
+	if err != nil {
+		return nil, &ExitError{Code: ExitUsage, Err: err}
+	}
+	return tmpl, nil
+}
+
+// templateVars returns the placeholder values for file, except the output
+// format. The file is only hashed when the template uses {hash8}.
+func templateVars(cmd *cobra.Command, tmpl *batch.Template, file, root string, now time.Time) (batch.TemplateVars, error) {
+	vars := batch.TemplateVars{
+		Stem:    fileStem(file),
+		Ext:     strings.TrimPrefix(filepath.Ext(file), "."),
+		Model:   getStringFlagOrConfig(cmd, "model", api.DefaultModel),
+		Mode:    getStringFlagOrConfig(cmd, "mode", GetConfig().DefaultMode),
+		Date:    now,
+		RelPath: fileStem(file),
+	}
+
+	if rel, ok := insideRoot(root, file); ok {
+		if dir := filepath.Dir(rel); dir != "." {
+			vars.Dir = dir
+			vars.RelPath = filepath.Join(dir, vars.Stem)
+		}
+	}
+
+	if tmpl.Uses("hash8") {
+		hash, err := cache.HashFile(file)
+		if err != nil {
+			return vars, &ExitError{Code: ExitFileIO, Err: err}
+		}
+		vars.Hash = hash
+	}
+
+	return vars, nil
+}
+
+// templateOutputs executes tmpl once per format. A template that does not
+// vary by format gets each format's extension instead, so that several
+// formats do not overwrite one file.
+func templateOutputs(tmpl *batch.Template, vars batch.TemplateVars, formats []string) []string {
+	if len(formats) > 1 && !tmpl.Uses("format") && !tmpl.Uses("outext") {
+		return formatOutputs(trimExt(tmpl.Execute(vars)), formats)
+	}
+
+	outputs := make([]string, len(formats))
+	for i, format := range formats {
+		vars.Format = format
+		vars.OutExt = strings.TrimPrefix(getExtensionForFormat(format), ".")
+		outputs[i] = tmpl.Execute(vars)
+	}
+	return outputs
+}
+
+// formatOutputs returns base with the extension of each format
+func formatOutputs(base string, formats []string) []string {
+	outputs := make([]string, len(formats))
+	for i, format := range formats {
+		outputs[i] = base + getExtensionForFormat(format)
+	}
+	return outputs
+}
+
+// trimExt returns path without its extension
+func trimExt(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
+// insideRoot returns file relative to root if it is inside root
+func insideRoot(root, file string) (string, bool) {
+	rel, err := relPath(root, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// inputRoot returns the directory that batch output paths are made relative to:
+// the directory itself, the directory of a file, or the fixed prefix of a glob pattern
+func inputRoot(inputPath string) string {
+	if strings.ContainsAny(inputPath, "*?[") {
+		dir := filepath.Dir(inputPath)
+		for strings.ContainsAny(dir, "*?[") {
+			dir = filepath.Dir(dir)
+		}
+		return dir
+	}
+
+	if info, err := os.Stat(inputPath); err == nil && info.IsDir() {
+		return inputPath
+	}
+	return filepath.Dir(inputPath)
+}
@@ -2,18 +2,28 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/serithemage/updoc/internal/api"
+	"github.com/serithemage/updoc/internal/batch"
+	"github.com/serithemage/updoc/internal/cache"
+	"github.com/serithemage/updoc/internal/config"
+	"github.com/serithemage/updoc/internal/jobs"
 	"github.com/serithemage/updoc/internal/output"
 	"github.com/spf13/cobra"
 )
 
 var parseCmd = &cobra.Command{
-	Use:   "parse <file|directory|pattern>",
+	Use:   "parse [file|directory|pattern]",
 	Short: "Parse a document or multiple documents",
 	Long: `Parse a document and convert it to structured text.
 
@@ -34,43 +44,129 @@ Examples:
   # Directory (non-recursive)
   updoc parse ./documents/ --output-dir ./results/
 
-  # Directory (recursive)
-  updoc parse ./documents/ --output-dir ./results/ --recursive`,
-	Args: cobra.ExactArgs(1),
+  # Directory (recursive), mirroring the directory tree under ./results/
+  updoc parse ./documents/ --output-dir ./results/ --recursive
+
+  # Request only markdown and return figures and tables as images
+  updoc parse document.pdf --output-formats markdown --base64-categories figure,table --json
+
+  # Save figures as image files and link them from the markdown
+  updoc parse document.pdf -o doc.md --extract-images ./images/
+
+  # Split into heading-aware chunks (JSON Lines) for RAG pipelines
+  updoc parse document.pdf -f chunks --chunk-size 512 --chunk-unit tokens -o chunks.jsonl
+
+  # Export every table as a CSV file
+  updoc parse document.pdf -o doc.md --tables-dir ./tables/
+
+  # Submit a large PDF with the async API and wait for the result
+  updoc parse large.pdf --async --wait -o large.md
+
+  # Submit a directory of large PDFs with the async API and collect the results
+  updoc parse ./scans/ --output-dir ./results/ --async
+
+  # Name output files from a template
+  updoc parse ./documents/ --output-dir ./results/ --output-template "{yyyy}/{stem}.{model}.md"
+
+  # Continue an interrupted batch run, then retry its failures
+  updoc parse ./documents/ --output-dir ./results/ --resume
+  updoc parse --retry-failed ./results/updoc-manifest.json
+
+  # Parse 4 files at a time
+  updoc parse ./documents/ --output-dir ./results/ --concurrency 4
+
+  # Use the options of the "scanned" preset from the config, overriding its format
+  updoc parse contract.pdf --preset scanned -f html`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runParse,
 }
 
 func init() {
-	parseCmd.Flags().StringP("format", "f", "", "output format: html, markdown, text (default from config or markdown)")
+	parseCmd.Flags().StringP("format", "f", "", "output format: html, markdown, text, json, chunks, or a comma-separated list (default from config or markdown)")
 	parseCmd.Flags().StringP("output", "o", "", "output file path (default: stdout)")
 	parseCmd.Flags().StringP("output-dir", "d", "", "output directory for batch processing")
 	parseCmd.Flags().BoolP("recursive", "r", false, "process directories recursively")
-	parseCmd.Flags().StringP("mode", "m", "", "parsing mode: standard, enhanced, auto (default from config or standard)")
-	parseCmd.Flags().String("ocr", "", "OCR setting: auto, force (default from config or auto)")
-	parseCmd.Flags().String("model", api.DefaultModel, "model to use")
-	parseCmd.Flags().Bool("chart-recognition", true, "convert charts to tables")
-	parseCmd.Flags().Bool("no-chart-recognition", false, "disable chart recognition")
-	parseCmd.Flags().Bool("merge-tables", false, "merge multi-page tables")
-	parseCmd.Flags().Bool("coordinates", true, "include coordinate information")
-	parseCmd.Flags().Bool("no-coordinates", false, "exclude coordinate information")
+	addParseRequestFlags(parseCmd)
+	addCacheFlags(parseCmd)
+	parseCmd.Flags().StringSlice("output-formats", nil, "content formats to request from the API: html, markdown, text (default all)")
+	parseCmd.Flags().StringSlice("base64-categories", nil, "element categories to return as base64 images, e.g. figure,table,chart")
+	parseCmd.Flags().String("extract-images", "", "directory to save figure/table images to; figures are linked from markdown/html output")
+	parseCmd.Flags().String("tables-dir", "", "directory to export table elements to as spreadsheet files")
+	parseCmd.Flags().String("tables-format", output.TableFormatCSV, "table export format: csv, tsv, xlsx")
+	parseCmd.Flags().Int("chunk-size", output.DefaultChunkSize, "maximum chunk size for --format chunks")
+	parseCmd.Flags().Int("chunk-overlap", output.DefaultChunkOverlap, "content shared between consecutive chunks for --format chunks")
+	parseCmd.Flags().String("chunk-unit", output.ChunkUnitChars, "chunk size unit: chars, tokens (approximate)")
 	parseCmd.Flags().BoolP("elements-only", "e", false, "output only elements")
 	parseCmd.Flags().BoolP("json", "j", false, "output as JSON")
-	parseCmd.Flags().BoolP("async", "a", false, "use async processing")
+	parseCmd.Flags().BoolP("async", "a", false, "use async processing (batch mode: submit all files, then poll for results)")
+	parseCmd.Flags().BoolP("wait", "w", false, "with --async, wait for the request to finish and write the result")
+	parseCmd.Flags().IntP("timeout", "t", 300, "with --async --wait, seconds to wait for the submitted request before giving up (0 = no limit)")
+	parseCmd.Flags().IntP("concurrency", "c", config.DefaultConcurrency, "number of files to parse in parallel in batch mode")
+	addLayoutFlags(parseCmd)
+	parseCmd.Flags().String("output-template", "", "output path template, e.g. {yyyy}/{stem}.{model}.md (relative to --output-dir in batch mode)")
+	parseCmd.Flags().Bool("resume", false, "skip files completed by a previous batch run into the same output directory")
+	parseCmd.Flags().String("preset", "", "apply a named set of options from the config (see 'updoc config preset list')")
+	parseCmd.Flags().String("retry-failed", "", "reprocess the files that failed in the run recorded by this manifest")
 
 	rootCmd.AddCommand(parseCmd)
 }
 
+// addParseRequestFlags registers the flags that control the parse request sent to the API
+func addParseRequestFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("mode", "m", "", "parsing mode: standard, enhanced, auto (default from config or standard)")
+	cmd.Flags().String("ocr", "", "OCR setting: auto, force (default from config or auto)")
+	cmd.Flags().String("model", api.DefaultModel, "model to use")
+	cmd.Flags().Bool("chart-recognition", true, "convert charts to tables")
+	cmd.Flags().Bool("no-chart-recognition", false, "disable chart recognition")
+	cmd.Flags().Bool("merge-tables", false, "merge multi-page tables (default from config)")
+	cmd.Flags().Bool("coordinates", true, "include coordinate information")
+	cmd.Flags().Bool("no-coordinates", false, "exclude coordinate information")
+}
+
 func runParse(cmd *cobra.Command, args []string) error {
-	inputPath := args[0]
+	if err := applyPreset(cmd); err != nil {
+		return err
+	}
+
 	outputDir, _ := cmd.Flags().GetString("output-dir")
 	recursive, _ := cmd.Flags().GetBool("recursive")
+	retryFailed, _ := cmd.Flags().GetString("retry-failed")
+
+	if retryFailed != "" && len(args) > 0 {
+		return newExitError(ExitUsage, "--retry-failed takes its files from the manifest; do not pass a file argument")
+	}
+	if retryFailed == "" && len(args) == 0 {
+		return newExitError(ExitUsage, "requires a file, directory or pattern to parse")
+	}
+	if wait, _ := cmd.Flags().GetBool("wait"); wait {
+		if async, _ := cmd.Flags().GetBool("async"); !async {
+			return newExitError(ExitUsage, "--wait requires --async")
+		}
+	}
 
 	// Get API key
-	apiKey := GetAPIKey(cmd)
-	if apiKey == "" {
-		return fmt.Errorf("API key not set. Set it with 'updoc config set api-key <your-key>' or UPSTAGE_API_KEY environment variable")
+	apiKey, err := GetAPIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := validateParseOptions(cmd); err != nil {
+		return err
+	}
+	if _, err := getOutputTemplate(cmd); err != nil {
+		return err
+	}
+	if outputPath, _ := cmd.Flags().GetString("output"); strings.ContainsAny(outputPath, "{}") {
+		if _, err := batch.ParseTemplate(outputPath); err != nil {
+			return &ExitError{Code: ExitUsage, Err: err}
+		}
 	}
 
+	if retryFailed != "" {
+		return retryFailedFiles(cmd, apiKey, retryFailed, outputDir)
+	}
+	inputPath := args[0]
+
 	// Collect files to process
 	files, err := collectFiles(inputPath, recursive)
 	if err != nil {
@@ -78,7 +174,7 @@ func runParse(cmd *cobra.Command, args []string) error {
 	}
 
 	if len(files) == 0 {
-		return fmt.Errorf("no supported files found matching: %s", inputPath)
+		return newExitError(ExitUsage, "no supported files found matching: %s", inputPath)
 	}
 
 	// Single file mode
@@ -88,7 +184,12 @@ func runParse(cmd *cobra.Command, args []string) error {
 
 	// Batch mode requires output-dir
 	if outputDir == "" {
-		return fmt.Errorf("--output-dir is required for batch processing (multiple files)")
+		return newExitError(ExitUsage, "--output-dir is required for batch processing (multiple files)")
+	}
+
+	jobs, err := planBatchJobs(cmd, files, inputRoot(inputPath), getFormats(cmd))
+	if err != nil {
+		return err
 	}
 
 	// Create output directory if needed
@@ -96,25 +197,118 @@ func runParse(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	return processBatch(cmd, apiKey, files, outputDir)
+	manifestPath := filepath.Join(outputDir, batch.ManifestName)
+	manifest := batch.New(manifestPath)
+	if resume, _ := cmd.Flags().GetBool("resume"); resume {
+		loaded, err := batch.Load(manifestPath)
+		switch {
+		case err == nil:
+			manifest = loaded
+		case os.IsNotExist(err):
+			Verbosef("No manifest found in %s, starting a new run\n", outputDir)
+		default:
+			return &ExitError{Code: ExitFileIO, Err: err}
+		}
+	}
+
+	return processBatch(cmd, apiKey, jobs, outputDir, manifest)
+}
+
+// singleOutputPaths returns the output path per format for a single input file:
+// -o with any placeholders expanded, or --output-template when -o is not set.
+// It returns nil to write to stdout.
+func singleOutputPaths(cmd *cobra.Command, file string) ([]string, error) {
+	outputPath, _ := cmd.Flags().GetString("output")
+	value := outputPath
+	if value == "" {
+		value, _ = cmd.Flags().GetString("output-template")
+	}
+	if value == "" || (value == outputPath && !strings.ContainsAny(value, "{}")) {
+		return outputPaths(cmd, outputPath), nil
+	}
+
+	tmpl, err := batch.ParseTemplate(value)
+	if err != nil {
+		return nil, &ExitError{Code: ExitUsage, Err: err}
+	}
+	vars, err := templateVars(cmd, tmpl, file, filepath.Dir(file), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return templateOutputs(tmpl, vars, getFormats(cmd)), nil
+}
+
+// retryFailedFiles reprocesses the files that failed in the run recorded by manifestPath.
+// Output goes to outputDir, or to the manifest's directory when outputDir is empty.
+func retryFailedFiles(cmd *cobra.Command, apiKey, manifestPath, outputDir string) error {
+	manifest, err := batch.Load(manifestPath)
+	if err != nil {
+		return &ExitError{Code: ExitFileIO, Err: fmt.Errorf("failed to load manifest: %w", err)}
+	}
+
+	files := manifest.Sources(batch.StatusFailed)
+	if len(files) == 0 {
+		Printf("No failed files in %s\n", manifestPath)
+		return nil
+	}
+
+	// Parse with the options of the recorded run, unless given again
+	for _, name := range slices.Sorted(maps.Keys(manifest.Options)) {
+		if cmd.Flags().Changed(name) || cmd.Flags().Lookup(name) == nil {
+			continue
+		}
+		if err := cmd.Flags().Set(name, manifest.Options[name]); err != nil {
+			return newExitError(ExitUsage, "manifest %s: invalid %s: %v", manifestPath, name, err)
+		}
+	}
+
+	// Reuse the output names of the previous run
+	previousDir := filepath.Dir(absPath(manifestPath))
+	formats := getFormats(cmd)
+	jobs := make([]batchJob, len(files))
+	for i, file := range files {
+		name := fileStem(file)
+		entry, _ := manifest.Get(file)
+		if rel, ok := insideRoot(previousDir, entry.Output); entry.Output != "" && ok {
+			name = trimExt(rel)
+		}
+		jobs[i] = batchJob{file: file, outputs: formatOutputs(name, formats)}
+	}
+
+	if outputDir == "" {
+		outputDir = filepath.Dir(manifestPath)
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	manifest.SetPath(filepath.Join(outputDir, batch.ManifestName))
+
+	return processBatch(cmd, apiKey, jobs, outputDir, manifest)
 }
 
+// collectFiles returns the supported documents matching inputPath
 func collectFiles(inputPath string, recursive bool) ([]string, error) {
+	return collectMatchingFiles(inputPath, recursive, api.IsSupportedFile)
+}
+
+// collectMatchingFiles returns the files matching inputPath, which may be a
+// file, a directory or a glob pattern, for which match returns true
+func collectMatchingFiles(inputPath string, recursive bool, match func(string) bool) ([]string, error) {
 	var files []string
 
 	// Check if it's a glob pattern
 	if strings.ContainsAny(inputPath, "*?[") {
 		matches, err := filepath.Glob(inputPath)
 		if err != nil {
-			return nil, fmt.Errorf("invalid glob pattern: %w", err)
+			return nil, newExitError(ExitUsage, "invalid glob pattern: %w", err)
 		}
-		for _, match := range matches {
-			info, err := os.Stat(match)
+		for _, m := range matches {
+			info, err := os.Stat(m)
 			if err != nil {
 				continue
 			}
-			if !info.IsDir() && api.IsSupportedFile(match) {
-				files = append(files, match)
+			if !info.IsDir() && match(m) {
+				files = append(files, m)
 			}
 		}
 		return files, nil
@@ -123,7 +317,7 @@ func collectFiles(inputPath string, recursive bool) ([]string, error) {
 	// Check if path exists
 	info, err := os.Stat(inputPath)
 	if os.IsNotExist(err) {
-		return nil, fmt.Errorf("file not found: %s", inputPath)
+		return nil, newExitError(ExitFileIO, "file not found: %s", inputPath)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to access path: %w", err)
@@ -131,8 +325,8 @@ func collectFiles(inputPath string, recursive bool) ([]string, error) {
 
 	// Single file
 	if !info.IsDir() {
-		if !api.IsSupportedFile(inputPath) {
-			return nil, fmt.Errorf("unsupported file format: %s", filepath.Ext(inputPath))
+		if !match(inputPath) {
+			return nil, newExitError(ExitUsage, "unsupported file format: %s", filepath.Ext(inputPath))
 		}
 		return []string{inputPath}, nil
 	}
@@ -143,7 +337,7 @@ func collectFiles(inputPath string, recursive bool) ([]string, error) {
 			if err != nil {
 				return err
 			}
-			if !info.IsDir() && api.IsSupportedFile(path) {
+			if !info.IsDir() && match(path) {
 				files = append(files, path)
 			}
 			return nil
@@ -159,7 +353,7 @@ func collectFiles(inputPath string, recursive bool) ([]string, error) {
 		for _, entry := range entries {
 			if !entry.IsDir() {
 				path := filepath.Join(inputPath, entry.Name())
-				if api.IsSupportedFile(path) {
+				if match(path) {
 					files = append(files, path)
 				}
 			}
@@ -171,80 +365,309 @@ func collectFiles(inputPath string, recursive bool) ([]string, error) {
 
 func processSingleFile(cmd *cobra.Command, apiKey string, filePath string) error {
 	req := buildParseRequest(cmd, filePath)
+	req.OnUploadProgress = uploadProgress(filepath.Base(filePath))
 
 	async, _ := cmd.Flags().GetBool("async")
-	if async {
+	wait, _ := cmd.Flags().GetBool("wait")
+	if async && !wait {
 		return runParseAsync(cmd, apiKey, req)
 	}
 
-	return runParseSync(cmd, apiKey, req)
+	paths, err := singleOutputPaths(cmd, filePath)
+	if err != nil {
+		return err
+	}
+	if async {
+		return runParseAsyncWait(cmd, apiKey, req, paths)
+	}
+	return runParseSync(cmd, apiKey, req, paths)
+}
+
+// batchResult is the outcome of parsing one file in batch mode
+type batchResult struct {
+	file   string
+	output string
+	err    error
 }
 
-func processBatch(cmd *cobra.Command, apiKey string, files []string, outputDir string) error {
-	format := getStringFlagOrConfig(cmd, "format", GetConfig().DefaultFormat)
-	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
-		format = "json"
+// batchFinishFunc records the outcome of processing jobs[i] into paths.
+// requestID is set for files parsed with the async API.
+type batchFinishFunc func(i int, paths []string, requestID string, err error)
+
+// processBatch parses files into outputDir, recording each outcome in manifest.
+// Files the manifest records as completed are skipped if they are unchanged
+// and their output still exists.
+func processBatch(cmd *cobra.Command, apiKey string, jobs []batchJob, outputDir string, manifest *batch.Manifest) error {
+	client := NewAPIClient(cmd, apiKey)
+
+	concurrency := getIntFlagOrConfig(cmd, "concurrency", GetConfig().Concurrency)
+	if concurrency < 1 {
+		return newExitError(ExitUsage, "--concurrency must be at least 1")
 	}
 
-	ext := getExtensionForFormat(format)
-	client := api.NewClient(apiKey, api.WithBaseURL(GetEndpoint(cmd)))
+	total := len(jobs)
+	jobs = skipCompleted(manifest, jobs, outputDir)
+	skipped := total - len(jobs)
+	if skipped > 0 {
+		Printf("Skipping %d files already completed\n", skipped)
+	}
 
-	var successCount, failCount int
-	var failedFiles []string
+	for _, job := range jobs {
+		manifest.Set(batch.Entry{Source: absPath(job.file), Status: batch.StatusPending})
+	}
+	manifest.SetOptions(batchOptions(cmd))
+	saveManifest(manifest)
 
-	Printf("Processing %d files...\n\n", len(files))
+	if concurrency > len(jobs) {
+		concurrency = len(jobs)
+	}
 
-	for _, filePath := range files {
-		baseName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
-		outputPath := filepath.Join(outputDir, baseName+ext)
+	Printf("Processing %d files...\n", len(jobs))
+	Verbosef("Using %d workers\n", concurrency)
+	Printf("\n")
 
-		Printf("Processing: %s... ", filepath.Base(filePath))
+	results := make([]batchResult, len(jobs))
+	var logMu sync.Mutex
 
-		req := buildParseRequest(cmd, filePath)
-		resp, err := client.Parse(context.Background(), req)
+	// finish records the outcome of a job in the manifest and prints it
+	finish := func(i int, paths []string, requestID string, err error) {
+		filePath := jobs[i].file
+		if err != nil && interrupted(cmd.Context()) {
+			err = errInterrupted
+		}
+		results[i] = batchResult{file: filePath, output: strings.Join(paths, ", "), err: err}
+
+		entry := batch.Entry{Source: absPath(filePath), Output: absPath(paths[0]), RequestID: requestID, Status: batch.StatusCompleted}
+		entry.Hash, _ = cache.HashFile(filePath)
 		if err != nil {
-			Printf("failed (%v)\n", err)
-			failCount++
-			failedFiles = append(failedFiles, filePath)
-			continue
+			entry.Status = batch.StatusFailed
+			entry.Error = err.Error()
 		}
+		manifest.Set(entry)
+		saveManifest(manifest)
 
-		result, err := formatResult(cmd, resp)
+		// Print each file's outcome as a single line so workers don't interleave
+		logMu.Lock()
 		if err != nil {
-			Printf("failed (%v)\n", err)
-			failCount++
-			failedFiles = append(failedFiles, filePath)
-			continue
+			Printf("Processing: %s... failed (%v)\n", filepath.Base(filePath), err)
+		} else {
+			Printf("Processing: %s... done -> %s\n", filepath.Base(filePath), results[i].output)
 		}
+		logMu.Unlock()
+	}
 
-		if err := os.WriteFile(outputPath, []byte(result), 0644); err != nil {
-			Printf("failed (%v)\n", err)
-			failCount++
-			failedFiles = append(failedFiles, filePath)
-			continue
+	if async, _ := cmd.Flags().GetBool("async"); async {
+		runBatchAsync(cmd, client, jobs, outputDir, manifest, concurrency, finish)
+	} else {
+		runBatchSync(cmd, client, jobs, outputDir, concurrency, finish)
+	}
+
+	// Files not started before an interrupt are recorded as interrupted so
+	// --resume and --retry-failed pick them up
+	for i, r := range results {
+		if r.file == "" {
+			results[i] = batchResult{file: jobs[i].file, err: errInterrupted}
+			manifest.Set(batch.Entry{Source: absPath(jobs[i].file), Status: batch.StatusFailed, Error: errInterrupted.Error()})
 		}
+	}
+	saveManifest(manifest)
 
-		Printf("done -> %s\n", outputPath)
-		successCount++
+	var successCount, failCount, interruptCount int
+	var failedFiles []string
+	var firstErr error
+	for _, r := range results {
+		if errors.Is(r.err, errInterrupted) {
+			interruptCount++
+			failedFiles = append(failedFiles, r.file)
+			continue
+		}
+		if r.err != nil {
+			failCount++
+			failedFiles = append(failedFiles, r.file)
+			if firstErr == nil {
+				firstErr = r.err
+			}
+		} else {
+			successCount++
+		}
 	}
 
 	// Print summary
 	Printf("\nSummary:\n")
-	Printf("  Total:   %d\n", len(files))
+	Printf("  Total:   %d\n", total)
 	Printf("  Success: %d\n", successCount)
 	Printf("  Failed:  %d\n", failCount)
+	if skipped > 0 {
+		Printf("  Skipped: %d\n", skipped)
+	}
+	if interruptCount > 0 {
+		Printf("  Interrupted: %d\n", interruptCount)
+	}
+	Printf("  Manifest: %s\n", manifest.Path())
 
 	if len(failedFiles) > 0 {
 		Printf("\nFailed files:\n")
 		for _, f := range failedFiles {
 			Printf("  - %s\n", f)
 		}
-		return fmt.Errorf("%d files failed to process", failCount)
+		Printf("\nRetry with: updoc parse --retry-failed %s\n", manifest.Path())
+	}
+	if interruptCount > 0 {
+		return newExitError(ExitInterrupted, "interrupted: %d of %d files not processed", interruptCount, len(jobs))
+	}
+	if failCount > 0 {
+		return batchError(failCount, len(jobs), firstErr)
 	}
 
 	return nil
 }
 
+// runBatchSync parses jobs with the synchronous API using concurrency workers
+func runBatchSync(cmd *cobra.Command, client *api.Client, jobs []batchJob, outputDir string, concurrency int, finish batchFinishFunc) {
+	queue := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				paths := jobs[i].paths(outputDir)
+				err := parseToFile(cmd, client, jobs[i], paths)
+				finish(i, paths, "", err)
+			}
+		}()
+	}
+
+	// Stop handing out files once the run is interrupted
+	ctx := cmd.Context()
+dispatch:
+	for i := range jobs {
+		select {
+		case queue <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// skipCompleted returns the jobs that still need processing: those the manifest
+// does not record as completed into the same output, that changed since, or
+// whose outputs are gone
+func skipCompleted(manifest *batch.Manifest, jobs []batchJob, outputDir string) []batchJob {
+	var pending []batchJob
+	for _, job := range jobs {
+		entry, ok := manifest.Get(absPath(job.file))
+		paths := job.paths(outputDir)
+		if ok && entry.Status == batch.StatusCompleted && entry.Output == absPath(paths[0]) && allExist(paths) {
+			if hash, err := cache.HashFile(job.file); err == nil && hash == entry.Hash {
+				Verbosef("Skipping %s (completed)\n", job.file)
+				continue
+			}
+		}
+		pending = append(pending, job)
+	}
+	return pending
+}
+
+// allExist reports whether every path exists
+func allExist(paths []string) bool {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// saveManifest writes the manifest, warning instead of failing the batch on error
+func saveManifest(manifest *batch.Manifest) {
+	if err := manifest.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// absPath returns the absolute form of path, or path itself if it cannot be resolved
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// parseToFile parses a batch job's file and writes the formatted result to paths
+func parseToFile(cmd *cobra.Command, client *api.Client, job batchJob, paths []string) error {
+	req := buildParseRequest(cmd, job.file)
+	resp, err := parseDocument(cmd.Context(), cmd, client, req)
+	if err != nil {
+		return err
+	}
+
+	return writeResultFile(cmd, resp, job.name(), paths)
+}
+
+// writeResultFile formats resp and writes each output format to its path. Images
+// and tables are extracted into per-document directories named after the output name.
+func writeResultFile(cmd *cobra.Command, resp *api.ParseResponse, name string, paths []string) error {
+	imageDir := ""
+	if dir, _ := cmd.Flags().GetString("extract-images"); dir != "" {
+		imageDir = filepath.Join(dir, name)
+	}
+	images, err := extractImages(resp, imageDir)
+	if err != nil {
+		return err
+	}
+
+	if err := exportTables(cmd, resp, name, true); err != nil {
+		return err
+	}
+
+	for i, format := range getFormats(cmd) {
+		result, err := formatResult(cmd, resp, linkImages(images, paths[i]), format)
+		if err != nil {
+			return err
+		}
+		if err := writeOutputFile(paths[i], result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeOutputFile writes result to path, creating its directory. The file is
+// replaced atomically so an interrupted run never leaves a truncated output.
+func writeOutputFile(path, result string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".updoc-*")
+	if err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if _, err := tmp.WriteString(result); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
 func buildParseRequest(cmd *cobra.Command, filePath string) *api.ParseRequest {
 	req := api.NewParseRequest(filePath)
 
@@ -260,16 +683,171 @@ func buildParseRequest(cmd *cobra.Command, filePath string) *api.ParseRequest {
 		req.ChartRecognition = false
 	}
 
-	req.MergeTables, _ = cmd.Flags().GetBool("merge-tables")
+	req.MergeTables = GetConfig().MergeTables
+	if cmd.Flags().Changed("merge-tables") {
+		req.MergeTables, _ = cmd.Flags().GetBool("merge-tables")
+	}
 
 	req.Coordinates, _ = cmd.Flags().GetBool("coordinates")
 	if noCoords, _ := cmd.Flags().GetBool("no-coordinates"); noCoords {
 		req.Coordinates = false
 	}
 
+	if cmd.Flags().Changed("output-formats") {
+		req.OutputFormats = getListFlag(cmd, "output-formats")
+	}
+	req.Base64Categories = getListFlag(cmd, "base64-categories")
+	if imageDir, _ := cmd.Flags().GetString("extract-images"); imageDir != "" && len(req.Base64Categories) == 0 {
+		req.Base64Categories = []string{api.CategoryFigure}
+	}
+
 	return req
 }
 
+// batchOptions returns the effective parse and output options of a batch run
+// as flag values by flag name, to be recorded in its manifest
+func batchOptions(cmd *cobra.Command) map[string]string {
+	req := buildParseRequest(cmd, "")
+	options := map[string]string{
+		"format":            strings.Join(getFormats(cmd), ","),
+		"model":             req.Model,
+		"mode":              req.Mode,
+		"ocr":               req.OCR,
+		"chart-recognition": strconv.FormatBool(req.ChartRecognition),
+		"merge-tables":      strconv.FormatBool(req.MergeTables),
+		"coordinates":       strconv.FormatBool(req.Coordinates),
+		"output-formats":    strings.Join(req.OutputFormats, ","),
+		"base64-categories": strings.Join(req.Base64Categories, ","),
+	}
+	for _, name := range []string{"elements-only", "extract-images", "tables-dir", "tables-format", "chunk-size", "chunk-overlap", "chunk-unit"} {
+		options[name] = cmd.Flags().Lookup(name).Value.String()
+	}
+	if !cmd.Flags().Changed("output-formats") {
+		delete(options, "output-formats")
+	}
+	for name, value := range options {
+		if value == "" {
+			delete(options, name)
+		}
+	}
+	return options
+}
+
+// validateParseOptions checks option values before any file is uploaded
+func validateParseOptions(cmd *cobra.Command) error {
+	outputFormats := getListFlag(cmd, "output-formats")
+	for _, f := range outputFormats {
+		if !api.IsValidOutputFormat(f) {
+			return newExitError(ExitUsage, "invalid output format %q: must be html, markdown, or text", f)
+		}
+	}
+
+	for _, c := range getListFlag(cmd, "base64-categories") {
+		if !api.IsValidCategory(c) {
+			return newExitError(ExitUsage, "invalid base64 category %q: must be one of %s", c, strings.Join(api.Categories, ", "))
+		}
+	}
+
+	if tablesDir, _ := cmd.Flags().GetString("tables-dir"); tablesDir != "" &&
+		cmd.Flags().Changed("output-formats") && !slices.Contains(outputFormats, api.OutputFormatHTML) {
+		return newExitError(ExitUsage, "--tables-dir requires \"html\" in --output-formats")
+	}
+
+	// Results go to stdout unless written to files, or only submitted with --async
+	outputPath, _ := cmd.Flags().GetString("output")
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+	outputTemplate, _ := cmd.Flags().GetString("output-template")
+	async, _ := cmd.Flags().GetBool("async")
+	wait, _ := cmd.Flags().GetBool("wait")
+	retryFailed, _ := cmd.Flags().GetString("retry-failed")
+	toStdout := outputPath == "" && outputDir == "" && outputTemplate == "" && retryFailed == "" && (!async || wait)
+	if err := validateOutputOptions(cmd, toStdout); err != nil {
+		return err
+	}
+
+	// The formatted output is empty unless its content format was requested
+	if cmd.Flags().Changed("output-formats") {
+		for _, format := range getFormats(cmd) {
+			switch {
+			case format == "chunks":
+				if !slices.Contains(outputFormats, api.OutputFormatMarkdown) && !slices.Contains(outputFormats, api.OutputFormatText) {
+					return newExitError(ExitUsage, "--format chunks requires markdown or text in --output-formats")
+				}
+			case api.IsValidOutputFormat(format) && !slices.Contains(outputFormats, format):
+				return newExitError(ExitUsage, "--format %s requires %q in --output-formats", format, format)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateOutputOptions checks the format, table export and chunk options
+// shared by parse and convert. toStdout is set when results are written to stdout.
+func validateOutputOptions(cmd *cobra.Command, toStdout bool) error {
+	if tablesFormat, _ := cmd.Flags().GetString("tables-format"); !slices.Contains(output.ValidTableFormats, tablesFormat) {
+		return newExitError(ExitUsage, "invalid --tables-format %q: must be csv, tsv, or xlsx", tablesFormat)
+	}
+
+	formats := getFormats(cmd)
+	if len(formats) == 0 {
+		return newExitError(ExitUsage, "--format must name at least one format")
+	}
+	for _, format := range formats {
+		if _, err := output.NewFormatter(format); err != nil {
+			return newExitError(ExitUsage, "unsupported format %q: must be html, markdown, text, json, or chunks", format)
+		}
+	}
+
+	// Several formats cannot share stdout
+	if len(formats) > 1 && toStdout {
+		return newExitError(ExitUsage, "multiple formats (%s) require --output or --output-dir", strings.Join(formats, ","))
+	}
+
+	if slices.Contains(formats, "chunks") {
+		f := &output.ChunksFormatter{MaxSize: output.DefaultChunkSize, Overlap: output.DefaultChunkOverlap, Unit: output.ChunkUnitChars}
+		applyChunkOptions(cmd, f)
+		if f.MaxSize < 1 {
+			return newExitError(ExitUsage, "--chunk-size must be at least 1")
+		}
+		if f.Overlap < 0 || f.Overlap >= f.MaxSize {
+			return newExitError(ExitUsage, "--chunk-overlap must be between 0 and --chunk-size")
+		}
+		if f.Unit != output.ChunkUnitChars && f.Unit != output.ChunkUnitTokens {
+			return newExitError(ExitUsage, "invalid --chunk-unit %q: must be chars or tokens", f.Unit)
+		}
+	}
+
+	return nil
+}
+
+// applyChunkOptions sets chunking options from flags that were given explicitly
+func applyChunkOptions(cmd *cobra.Command, f *output.ChunksFormatter) {
+	if cmd.Flags().Changed("chunk-size") {
+		f.MaxSize, _ = cmd.Flags().GetInt("chunk-size")
+	}
+	if cmd.Flags().Changed("chunk-overlap") {
+		f.Overlap, _ = cmd.Flags().GetInt("chunk-overlap")
+	}
+	if cmd.Flags().Changed("chunk-unit") {
+		f.Unit, _ = cmd.Flags().GetString("chunk-unit")
+	}
+}
+
+// getListFlag returns a comma-separated flag as a list of trimmed, lowercase values
+func getListFlag(cmd *cobra.Command, flag string) []string {
+	values, _ := cmd.Flags().GetStringSlice(flag)
+
+	var result []string
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v != "" && !slices.Contains(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
 func getExtensionForFormat(format string) string {
 	switch format {
 	case "html":
@@ -278,19 +856,127 @@ func getExtensionForFormat(format string) string {
 		return ".txt"
 	case "json":
 		return ".json"
+	case "chunks":
+		return ".jsonl"
 	default:
 		return ".md"
 	}
 }
 
-func formatResult(cmd *cobra.Command, resp *api.ParseResponse) (string, error) {
-	elementsOnly, _ := cmd.Flags().GetBool("elements-only")
-	jsonOutput, _ := cmd.Flags().GetBool("json")
+// extractImages writes element images to imageDir and returns their paths,
+// keyed by element ID. It does nothing when imageDir is empty.
+func extractImages(resp *api.ParseResponse, imageDir string) (map[int]string, error) {
+	if imageDir == "" {
+		return nil, nil
+	}
 
-	format := getStringFlagOrConfig(cmd, "format", GetConfig().DefaultFormat)
-	if jsonOutput {
-		format = "json"
+	images, err := output.ExtractImages(resp, imageDir)
+	if err != nil {
+		return nil, err
 	}
+	Verbosef("Extracted %d images to %s\n", len(images), imageDir)
+
+	return images, nil
+}
+
+// linkImages returns the image paths relative to the directory of outputPath,
+// or to the working directory for stdout
+func linkImages(images map[int]string, outputPath string) map[int]string {
+	if images == nil {
+		return nil
+	}
+
+	baseDir := "."
+	if outputPath != "" {
+		baseDir = filepath.Dir(outputPath)
+	}
+	links := make(map[int]string, len(images))
+	for id, path := range images {
+		links[id] = path
+		if rel, err := relPath(baseDir, path); err == nil {
+			links[id] = rel
+		}
+	}
+	return links
+}
+
+// exportTables writes the response's table elements to the --tables-dir directory.
+// CSV and TSV files go into a subdirectory named after the document when perDocument
+// is set; XLSX workbooks are always named after the document.
+// It does nothing when --tables-dir is not set.
+func exportTables(cmd *cobra.Command, resp *api.ParseResponse, name string, perDocument bool) error {
+	dir, _ := cmd.Flags().GetString("tables-dir")
+	if dir == "" {
+		return nil
+	}
+	format, _ := cmd.Flags().GetString("tables-format")
+	if perDocument && format != output.TableFormatXLSX {
+		dir = filepath.Join(dir, name)
+	} else {
+		dir = filepath.Join(dir, filepath.Dir(name))
+	}
+	name = filepath.Base(name)
+
+	tables, err := output.ExtractTables(resp)
+	if err != nil {
+		return err
+	}
+
+	paths, err := output.WriteTables(tables, dir, name, format)
+	if err != nil {
+		return err
+	}
+	Verbosef("Exported %d tables to %s\n", len(tables), dir)
+	for _, path := range paths {
+		Verbosef("  %s\n", path)
+	}
+
+	return nil
+}
+
+// fileStem returns the file name without directory and extension
+func fileStem(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// relPath returns target relative to base, resolving both to absolute paths first
+func relPath(base, target string) (string, error) {
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(absBase, absTarget)
+}
+
+// getFormats returns the effective output formats: json with --json,
+// otherwise the comma-separated --format or the configured default
+func getFormats(cmd *cobra.Command) []string {
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		return []string{"json"}
+	}
+	return config.SplitFormats(getStringFlagOrConfig(cmd, "format", GetConfig().DefaultFormat))
+}
+
+// outputPaths returns the output path for each format: outputPath itself for
+// a single format, otherwise outputPath with each format's extension.
+// It returns nil when outputPath is empty.
+func outputPaths(cmd *cobra.Command, outputPath string) []string {
+	if outputPath == "" {
+		return nil
+	}
+	formats := getFormats(cmd)
+	if len(formats) == 1 {
+		return []string{outputPath}
+	}
+	return formatOutputs(trimExt(outputPath), formats)
+}
+
+func formatResult(cmd *cobra.Command, resp *api.ParseResponse, images map[int]string, format string) (string, error) {
+	elementsOnly, _ := cmd.Flags().GetBool("elements-only")
 
 	var formatter output.Formatter
 	var err error
@@ -300,40 +986,51 @@ func formatResult(cmd *cobra.Command, resp *api.ParseResponse) (string, error) {
 	} else {
 		formatter, err = output.NewFormatter(format)
 		if err != nil {
-			return "", err
+			return "", &ExitError{Code: ExitUsage, Err: err}
 		}
 	}
 
+	// Link extracted figure images from documents that can embed them
+	switch f := formatter.(type) {
+	case *output.MarkdownFormatter:
+		f.ImagePaths = images
+	case *output.HTMLFormatter:
+		f.ImagePaths = images
+	case *output.ChunksFormatter:
+		applyChunkOptions(cmd, f)
+	}
+
 	return formatter.Format(resp)
 }
 
-func runParseSync(cmd *cobra.Command, apiKey string, req *api.ParseRequest) error {
-	client := api.NewClient(apiKey, api.WithBaseURL(GetEndpoint(cmd)))
+func runParseSync(cmd *cobra.Command, apiKey string, req *api.ParseRequest, paths []string) error {
+	client := NewAPIClient(cmd, apiKey)
 
 	Verbosef("Parsing file: %s\n", req.FilePath)
 	Verbosef("Model: %s, Mode: %s, OCR: %s\n", req.Model, req.Mode, req.OCR)
 
 	Printf("Parsing %s...\n", filepath.Base(req.FilePath))
 
-	resp, err := client.Parse(context.Background(), req)
+	resp, err := parseDocument(cmd.Context(), cmd, client, req)
 	if err != nil {
 		return fmt.Errorf("parse failed: %w", err)
 	}
 
 	Verbosef("Parsed %d pages\n", resp.Usage.Pages)
 
-	return outputResult(cmd, resp)
+	return outputResultTo(cmd, resp, fileStem(req.FilePath), paths)
 }
 
 func runParseAsync(cmd *cobra.Command, apiKey string, req *api.ParseRequest) error {
-	client := api.NewClient(apiKey, api.WithBaseURL(GetEndpoint(cmd)))
+	client := NewAPIClient(cmd, apiKey)
 
 	Verbosef("Submitting async parse request for: %s\n", req.FilePath)
 
-	resp, err := client.ParseAsync(context.Background(), req)
+	resp, err := client.ParseAsync(cmd.Context(), req)
 	if err != nil {
 		return fmt.Errorf("async parse failed: %w", err)
 	}
+	recordJob(jobs.New(resp.RequestID, req, GetEndpoint(cmd)))
 
 	Printf("Request submitted successfully\n")
 	Printf("Request ID: %s\n", resp.RequestID)
@@ -344,21 +1041,108 @@ func runParseAsync(cmd *cobra.Command, apiKey string, req *api.ParseRequest) err
 	return nil
 }
 
-func outputResult(cmd *cobra.Command, resp *api.ParseResponse) error {
+// runParseAsyncWait submits req with the async API, shows progress until the
+// request finishes and writes the result like a synchronous parse. --timeout
+// only limits the wait, not the upload. When the wait is interrupted or times
+// out the request keeps running on the server.
+func runParseAsyncWait(cmd *cobra.Command, apiKey string, req *api.ParseRequest, paths []string) error {
+	client := NewAPIClient(cmd, apiKey)
+
+	ctx := cmd.Context()
+	waitCtx := ctx
+	timeout, _ := cmd.Flags().GetInt("timeout")
+
+	Printf("Parsing %s...\n", filepath.Base(req.FilePath))
+
+	var requestID string
+	resp, err := cachedParse(cmd, req, func() (*api.ParseResponse, error) {
+		submitted, err := client.ParseAsync(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("async parse failed: %w", err)
+		}
+		requestID = submitted.RequestID
+		record := jobs.New(requestID, req, GetEndpoint(cmd))
+		recordJob(record)
+		Printf("Request ID: %s\n", requestID)
+
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			waitCtx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+			defer cancel()
+		}
+
+		progress, endProgress := statusProgress(filepath.Base(req.FilePath))
+		resp, err := client.WaitForCompletion(waitCtx, requestID, api.WaitOptions{
+			InitialDelay: api.DefaultPollInterval,
+			OnStatus: func(status *api.StatusResponse) {
+				record.Update(status)
+				progress(status)
+			},
+		})
+		endProgress()
+		recordJob(record)
+		return resp, asyncError(err)
+	})
+	if err != nil {
+		if requestID != "" {
+			switch {
+			case errors.Is(waitCtx.Err(), context.DeadlineExceeded):
+				return newExitError(ExitTimeout, "timed out after %ds: request %s is still running, resume with: updoc result %s", timeout, requestID, requestID)
+			case ctx.Err() != nil:
+				return newExitError(ExitInterrupted, "interrupted: request %s is still running, resume with: updoc result %s", requestID, requestID)
+			}
+		}
+		return err
+	}
+
+	Verbosef("Parsed %d pages\n", resp.Usage.Pages)
+
+	return outputResultTo(cmd, resp, fileStem(req.FilePath), paths)
+}
+
+// outputResult formats resp and writes it to --output or stdout.
+// name identifies the document in exported table file names.
+func outputResult(cmd *cobra.Command, resp *api.ParseResponse, name string) error {
 	outputPath, _ := cmd.Flags().GetString("output")
+	return outputResultTo(cmd, resp, name, outputPaths(cmd, outputPath))
+}
 
-	result, err := formatResult(cmd, resp)
+// outputResultTo formats resp and writes each output format to its path,
+// or the only format to stdout when paths is empty
+func outputResultTo(cmd *cobra.Command, resp *api.ParseResponse, name string, paths []string) error {
+	formats := getFormats(cmd)
+	if len(paths) == 0 && len(formats) > 1 {
+		return newExitError(ExitUsage, "multiple formats (%s) require --output", strings.Join(formats, ","))
+	}
+
+	imageDir, _ := cmd.Flags().GetString("extract-images")
+	images, err := extractImages(resp, imageDir)
 	if err != nil {
-		return fmt.Errorf("failed to format output: %w", err)
+		return err
 	}
 
-	if outputPath != "" {
-		if err := os.WriteFile(outputPath, []byte(result), 0644); err != nil {
-			return fmt.Errorf("failed to write output file: %w", err)
+	if err := exportTables(cmd, resp, name, false); err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		result, err := formatResult(cmd, resp, linkImages(images, ""), formats[0])
+		if err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
 		}
-		Printf("Output written to: %s\n", outputPath)
-	} else {
 		fmt.Println(result)
+		return nil
+	}
+
+	for i, format := range formats {
+		result, err := formatResult(cmd, resp, linkImages(images, paths[i]), format)
+		if err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+		if err := writeOutputFile(paths[i], result); err != nil {
+			return err
+		}
+		Printf("Output written to: %s\n", paths[i])
 	}
 
 	return nil
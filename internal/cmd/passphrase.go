@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/serithemage/updoc/internal/config"
+	"golang.org/x/term"
+)
+
+var errNoPassphrase = fmt.Errorf("the API key is encrypted: set %s or run updoc in a terminal to enter the passphrase", config.EnvPassphrase)
+
+// readPassphrase returns $UPDOC_PASSPHRASE, or prompts for the passphrase on
+// the terminal without echo. With confirm, it is asked for twice.
+func readPassphrase(prompt string, confirm bool) (string, error) {
+	if passphrase := os.Getenv(config.EnvPassphrase); passphrase != "" {
+		return passphrase, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errNoPassphrase
+	}
+
+	passphrase, err := promptHidden(fd, prompt)
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := promptHidden(fd, "Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// promptHidden prints prompt to stderr and reads a line without echo
+func promptHidden(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(line), nil
+}
+
+// unlockAPIKey decrypts the encrypted API key of the selected profile
+func unlockAPIKey(settings *config.Config) (string, error) {
+	passphrase, err := readPassphrase(fmt.Sprintf("Passphrase for the API key of profile %s: ", profileName), false)
+	if err != nil {
+		return "", &ExitError{Code: ExitAuth, Err: err}
+	}
+	if err := settings.UnlockAPIKey(passphrase); err != nil {
+		return "", &ExitError{Code: ExitAuth, Err: err}
+	}
+	return settings.APIKey, nil
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/serithemage/updoc/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configPresetCmd = &cobra.Command{
+	Use:   "preset",
+	Short: "Show parse presets",
+	Long: `Show the parse presets defined under "presets:" in the config file or the
+project file. A preset is selected with 'updoc parse --preset <name>'; flags
+given on the command line override its options.`,
+}
+
+var configPresetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List presets with the options they set",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+		names := cfg.PresetNames()
+		if len(names) == 0 {
+			fmt.Println("No presets defined.")
+			return
+		}
+		for _, name := range names {
+			fmt.Printf("%-16s  %s\n", name, presetCommandLine(cfg.Presets[name]))
+		}
+	},
+}
+
+var configPresetShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show the options of a preset",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		preset, err := GetConfig().Preset(args[0])
+		if err != nil {
+			return &ExitError{Code: ExitUsage, Err: err}
+		}
+
+		fmt.Printf("Preset %s:\n", args[0])
+		fmt.Println()
+		for _, flag := range preset.Flags() {
+			fmt.Printf("  %-18s%s\n", flag.Name+":", flag.Value)
+		}
+		fmt.Println()
+		fmt.Printf("Equivalent to: updoc parse %s\n", presetCommandLine(preset))
+		return nil
+	},
+}
+
+// presetCommandLine returns the parse flags a preset stands for
+func presetCommandLine(preset *config.Preset) string {
+	var args []string
+	for _, flag := range preset.Flags() {
+		switch flag.Value {
+		case "true":
+			args = append(args, "--"+flag.Name)
+		case "false":
+			args = append(args, "--"+flag.Name+"=false")
+		default:
+			value := flag.Value
+			if strings.ContainsAny(value, " \t\"'{}*?$") {
+				value = fmt.Sprintf("%q", value)
+			}
+			args = append(args, "--"+flag.Name+" "+value)
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// applyPreset sets the flags of the preset selected with --preset that were
+// not given on the command line
+func applyPreset(cmd *cobra.Command) error {
+	name, _ := cmd.Flags().GetString("preset")
+	if name == "" {
+		return nil
+	}
+	preset, err := GetConfig().Preset(name)
+	if err != nil {
+		return &ExitError{Code: ExitUsage, Err: err}
+	}
+
+	Verbosef("Using preset %s\n", name)
+	for _, flag := range preset.Flags() {
+		if cmd.Flags().Changed(flag.Name) {
+			continue
+		}
+		if err := cmd.Flags().Set(flag.Name, flag.Value); err != nil {
+			return newExitError(ExitUsage, "preset %s: invalid %s: %v", name, flag.Name, err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	configPresetCmd.AddCommand(configPresetListCmd)
+	configPresetCmd.AddCommand(configPresetShowCmd)
+
+	configCmd.AddCommand(configPresetCmd)
+}
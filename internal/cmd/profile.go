@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/serithemage/updoc/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage configuration profiles",
+	Long: `Manage named configuration profiles, each with its own API key, endpoint
+and defaults.
+
+The top-level settings of the config file are the "default" profile. The
+profile in use is chosen with --profile, then $UPDOC_PROFILE, then
+'updoc config profile use'.`,
+}
+
+var configProfileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles, marking the one in use",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		file := configFile()
+		for _, name := range file.ProfileNames() {
+			settings, _ := file.Profile(name)
+			marker := " "
+			if name == profileName {
+				marker = "*"
+			}
+			apiKey := "(no api key)"
+			if settings.IsAPIKeyEncrypted() {
+				apiKey = "(encrypted)"
+			} else if settings.APIKey != "" {
+				apiKey = config.MaskAPIKey(settings.APIKey)
+			}
+			fmt.Printf("%s %-16s  %-40s  %s\n", marker, name, settings.GetEndpoint(), apiKey)
+		}
+	},
+}
+
+var configProfileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Select the profile used by default",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := configFile()
+		if err := file.UseProfile(args[0]); err != nil {
+			return &ExitError{Code: ExitUsage, Err: err}
+		}
+		if err := file.SaveTo(configFilePath()); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		Printf("Using profile %s\n", args[0])
+		if env := os.Getenv(config.EnvProfile); env != "" && env != args[0] {
+			fmt.Fprintf(os.Stderr, "Warning: %s=%s overrides the selected profile\n", config.EnvProfile, env)
+		}
+		return nil
+	},
+}
+
+var configProfileCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a profile",
+	Long: `Create a profile with default settings, or a copy of another profile
+with --from. The global --api-key and --endpoint flags are stored in the new
+profile; other settings can be changed with
+'updoc --profile <name> config set <key> <value>'.`,
+	Example: `  updoc config profile create staging --api-key up_xxx
+  updoc config profile create private --from default --endpoint https://upstage.internal/v1`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := configFile()
+
+		var from *config.Config
+		if name, _ := cmd.Flags().GetString("from"); name != "" {
+			var err error
+			if from, err = file.Profile(name); err != nil {
+				return &ExitError{Code: ExitUsage, Err: err}
+			}
+		}
+
+		created, err := file.CreateProfile(args[0], from)
+		if err != nil {
+			return &ExitError{Code: ExitUsage, Err: err}
+		}
+		if apiKey, _ := cmd.Flags().GetString("api-key"); apiKey != "" {
+			created.APIKey = apiKey
+		}
+		if endpoint, _ := cmd.Flags().GetString("endpoint"); endpoint != "" {
+			created.Endpoint = endpoint
+		}
+
+		if err := file.SaveTo(configFilePath()); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		Printf("Created profile %s\n", args[0])
+		return nil
+	},
+}
+
+var configProfileDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		file := configFile()
+		if _, err := file.Profile(name); err != nil {
+			return &ExitError{Code: ExitUsage, Err: err}
+		}
+
+		if force, _ := cmd.Flags().GetBool("force"); !force {
+			fmt.Printf("Are you sure you want to delete profile %s? [y/N] ", name)
+			reader := bufio.NewReader(os.Stdin)
+			response, _ := reader.ReadString('\n')
+			response = strings.TrimSpace(strings.ToLower(response))
+			if response != "y" && response != "yes" {
+				fmt.Println("Cancelled.")
+				return nil
+			}
+		}
+
+		if err := file.DeleteProfile(name); err != nil {
+			return &ExitError{Code: ExitUsage, Err: err}
+		}
+		if err := file.SaveTo(configFilePath()); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		Printf("Deleted profile %s\n", name)
+		return nil
+	},
+}
+
+func init() {
+	configProfileCreateCmd.Flags().String("from", "", "copy the settings of this profile")
+	configProfileDeleteCmd.Flags().Bool("force", false, "skip confirmation prompt")
+
+	configProfileCmd.AddCommand(configProfileListCmd)
+	configProfileCmd.AddCommand(configProfileUseCmd)
+	configProfileCmd.AddCommand(configProfileCreateCmd)
+	configProfileCmd.AddCommand(configProfileDeleteCmd)
+
+	configCmd.AddCommand(configProfileCmd)
+}
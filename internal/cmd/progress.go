@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/serithemage/updoc/internal/api"
+)
+
+// uploadProgress returns a callback that renders upload progress on stderr.
+// It returns nil in quiet mode or when stderr is not a terminal.
+func uploadProgress(name string) api.UploadProgressFunc {
+	if quiet || !isTerminal(os.Stderr) {
+		return nil
+	}
+
+	lastPercent := -1
+	return func(sent, total int64) {
+		percent := 100
+		if total > 0 {
+			percent = int(sent * 100 / total)
+		}
+		if percent == lastPercent {
+			return
+		}
+		lastPercent = percent
+
+		fmt.Fprintf(os.Stderr, "\033[2K\rUploading %s: %3d%% (%s/%s)",
+			name, percent, formatBytes(sent), formatBytes(total))
+		if sent >= total {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+}
+
+// statusProgress returns a callback that renders async processing progress on
+// stderr, and a function that ends the progress line. Without a terminal the
+// status is only logged in verbose mode.
+func statusProgress(name string) (update func(*api.StatusResponse), end func()) {
+	if quiet || !isTerminal(os.Stderr) {
+		update = func(status *api.StatusResponse) {
+			Verbosef("Status: %s, %d/%d pages\n", status.Status, status.PagesProcessed, status.TotalPages)
+		}
+		return update, func() {}
+	}
+
+	rendered := false
+	update = func(status *api.StatusResponse) {
+		rendered = true
+		if status.TotalPages > 0 {
+			fmt.Fprintf(os.Stderr, "\033[2K\rProcessing %s: %s, %d/%d pages (%d%%)",
+				name, status.Status, status.PagesProcessed, status.TotalPages, status.Progress)
+		} else {
+			fmt.Fprintf(os.Stderr, "\033[2K\rProcessing %s: %s", name, status.Status)
+		}
+	}
+	end = func() {
+		if rendered {
+			fmt.Fprintln(os.Stderr)
+			rendered = false
+		}
+	}
+	return update, end
+}
+
+// isTerminal reports whether the file is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// formatBytes formats a byte count for display
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
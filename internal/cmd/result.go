@@ -2,38 +2,50 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/serithemage/updoc/internal/api"
+	"github.com/serithemage/updoc/internal/output"
 	"github.com/spf13/cobra"
 )
 
 var resultCmd = &cobra.Command{
-	Use:   "result <request-id>",
+	Use:   "result <request-id|latest|file>",
 	Short: "Get async request result",
-	Long:  `Get the result of a completed asynchronous parse request.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runResult,
+	Long: `Get the result of a completed asynchronous parse request.
+
+The request can be given by ID, as "latest" for the most recently submitted
+request, or as the path of a submitted file (see updoc jobs).`,
+	Args: cobra.ExactArgs(1),
+	RunE: runResult,
 }
 
 func init() {
 	resultCmd.Flags().StringP("output", "o", "", "output file path")
-	resultCmd.Flags().StringP("format", "f", "", "output format: html, markdown, text")
+	resultCmd.Flags().StringP("format", "f", "", "output format: html, markdown, text, json, chunks, or a comma-separated list (default from config or markdown)")
 	resultCmd.Flags().BoolP("wait", "w", false, "wait until completion")
-	resultCmd.Flags().IntP("timeout", "t", 300, "wait timeout in seconds")
+	resultCmd.Flags().IntP("timeout", "t", 300, "wait timeout in seconds (0 = no limit)")
+	resultCmd.Flags().IntP("interval", "i", 5, "minimum polling interval in seconds when waiting; polling adapts up to 30s")
 	resultCmd.Flags().BoolP("json", "j", false, "output as JSON")
 	resultCmd.Flags().BoolP("elements-only", "e", false, "output only elements")
+	resultCmd.Flags().String("extract-images", "", "directory to save element images to (requires --base64-categories at submit time)")
+	resultCmd.Flags().String("tables-dir", "", "directory to export table elements to as spreadsheet files")
+	resultCmd.Flags().String("tables-format", output.TableFormatCSV, "table export format: csv, tsv, xlsx")
 
 	rootCmd.AddCommand(resultCmd)
 }
 
 func runResult(cmd *cobra.Command, args []string) error {
-	requestID := args[0]
+	apiKey, err := GetAPIKey(cmd)
+	if err != nil {
+		return err
+	}
 
-	apiKey := GetAPIKey(cmd)
-	if apiKey == "" {
-		return fmt.Errorf("API key not set")
+	requestID, err := resolveRequestID(args[0])
+	if err != nil {
+		return err
 	}
 
 	wait, _ := cmd.Flags().GetBool("wait")
@@ -45,16 +57,17 @@ func runResult(cmd *cobra.Command, args []string) error {
 }
 
 func getResult(cmd *cobra.Command, apiKey, requestID string) error {
-	client := api.NewClient(apiKey, api.WithBaseURL(GetEndpoint(cmd)))
+	client := newJobClient(cmd, apiKey, requestID)
 
 	// First check status
-	status, err := client.GetStatus(context.Background(), requestID)
+	status, err := client.GetStatus(cmd.Context(), requestID)
 	if err != nil {
 		return fmt.Errorf("failed to get status: %w", err)
 	}
+	updateJob(requestID, status)
 
 	if status.Status == "failed" {
-		return fmt.Errorf("request failed: %s", status.Error)
+		return newExitError(ExitAPI, "request failed: %s", status.Error)
 	}
 
 	if status.Status != "completed" {
@@ -62,49 +75,49 @@ func getResult(cmd *cobra.Command, apiKey, requestID string) error {
 	}
 
 	// Get result
-	resp, err := client.GetResult(context.Background(), requestID)
+	resp, err := client.GetResult(cmd.Context(), requestID)
 	if err != nil {
 		return fmt.Errorf("failed to get result: %w", err)
 	}
 
-	return outputResult(cmd, resp)
+	return outputResult(cmd, resp, requestID)
 }
 
 func waitAndGetResult(cmd *cobra.Command, apiKey, requestID string) error {
-	client := api.NewClient(apiKey, api.WithBaseURL(GetEndpoint(cmd)))
+	client := newJobClient(cmd, apiKey, requestID)
 	timeout, _ := cmd.Flags().GetInt("timeout")
+	interval, _ := cmd.Flags().GetInt("interval")
 
-	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+	ctx := cmd.Context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
 
 	Printf("Waiting for request %s to complete...\n", requestID)
 
-	for {
-		status, err := client.GetStatus(context.Background(), requestID)
-		if err != nil {
-			return fmt.Errorf("failed to get status: %w", err)
-		}
-
-		if status.Status == "completed" {
-			Printf("Request completed!\n")
-			resp, err := client.GetResult(context.Background(), requestID)
-			if err != nil {
-				return fmt.Errorf("failed to get result: %w", err)
-			}
-			return outputResult(cmd, resp)
-		}
-
-		if status.Status == "failed" {
-			return fmt.Errorf("request failed: %s", status.Error)
-		}
-
-		if time.Now().After(deadline) {
-			return fmt.Errorf("timeout waiting for completion (status: %s, progress: %d%%)", status.Status, status.Progress)
+	var last *api.StatusResponse
+	progress, endProgress := statusProgress(requestID)
+	resp, err := client.WaitForCompletion(ctx, requestID, api.WaitOptions{
+		Interval: time.Duration(interval) * time.Second,
+		OnStatus: func(status *api.StatusResponse) {
+			last = status
+			updateJob(requestID, status)
+			progress(status)
+		},
+	})
+	endProgress()
+	if errors.Is(err, context.DeadlineExceeded) {
+		if last != nil {
+			return newExitError(ExitTimeout, "timeout waiting for completion (status: %s, progress: %d%%)", last.Status, last.Progress)
 		}
-
-		Verbosef("Status: %s, Progress: %d%%\n", status.Status, status.Progress)
-
-		<-ticker.C
+		return newExitError(ExitTimeout, "timeout waiting for completion")
+	}
+	if err != nil {
+		return asyncError(err)
 	}
+
+	Printf("Request completed!\n")
+	return outputResult(cmd, resp, requestID)
 }
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"sync"
+	"time"
 
+	"github.com/serithemage/updoc/internal/api"
 	"github.com/serithemage/updoc/internal/config"
 	"github.com/spf13/cobra"
 )
@@ -17,9 +21,17 @@ var (
 
 var (
 	cfgFile string
-	cfg     *config.Config
+	cfg     *config.Config // the config file, with all profiles
 	verbose bool
 	quiet   bool
+
+	profileFlag string
+	profileName string         // name of the selected profile
+	profile     *config.Config // effective settings: the selected profile, project file and environment
+	profileErr  error          // set when the selected profile does not exist or the project file is invalid
+
+	// settingOrigins maps config keys to where their effective value came from
+	settingOrigins = map[string]string{}
 )
 
 var rootCmd = &cobra.Command{
@@ -31,19 +43,49 @@ var rootCmd = &cobra.Command{
 구조화된 텍스트(HTML, Markdown, Text)로 변환합니다.`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		commandStarted = true
+		// Profile management commands must work while the selected profile is missing
+		// or the project file is invalid
+		if profileErr != nil && cmd.Parent() != configProfileCmd {
+			return &ExitError{Code: ExitUsage, Err: profileErr}
+		}
+		return validateClientFlags(cmd)
+	},
 }
 
-// Execute runs the root command
+// commandStarted is set once flags and arguments have been validated
+var commandStarted bool
+
+// Execute runs the root command. SIGINT and SIGTERM cancel the context
+// passed to the command instead of killing the process.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := withInterrupt(context.Background())
+	defer stop()
+
+	err := rootCmd.ExecuteContext(ctx)
+	if err != nil && !commandStarted {
+		// Cobra failed before running the command: unknown command, bad flag or argument count
+		return &ExitError{Code: ExitUsage, Err: err}
+	}
+	if err != nil && interrupted(ctx) && ExitCode(err) != ExitInterrupted {
+		// The command failed because its requests were canceled
+		return errInterrupted
+	}
+	return err
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file path")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "configuration profile to use (default from $UPDOC_PROFILE or 'config profile use')")
 	rootCmd.PersistentFlags().String("api-key", "", "Upstage API key")
 	rootCmd.PersistentFlags().String("endpoint", "", "API endpoint URL (for private hosting or AWS Bedrock)")
+	rootCmd.PersistentFlags().Int("retries", config.DefaultRetries, "maximum retries for transient API errors (0 to disable)")
+	rootCmd.PersistentFlags().Int("retry-max-wait", config.DefaultRetryMaxWait, "maximum wait between retries in seconds")
+	rootCmd.PersistentFlags().Float64("rate-limit", 0, "maximum API requests per second (0 = unlimited)")
+	rootCmd.PersistentFlags().Int("pages-per-minute", 0, "maximum document pages uploaded per minute (0 = unlimited)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "suppress progress messages")
 }
@@ -63,29 +105,149 @@ func initConfig() {
 		cfg = config.New()
 	}
 
-	// Override with environment variables
-	cfg.LoadFromEnv()
+	// Select the profile; a missing profile is reported when the command runs
+	profileName = cfg.ProfileName(profileFlag)
+	stored, err := cfg.Profile(profileName)
+	profileErr = err
+	if err != nil {
+		stored = config.New()
+	}
+
+	userOrigin := fmt.Sprintf("user config (%s)", configFilePath())
+	if profileName != config.DefaultProfile {
+		userOrigin = fmt.Sprintf("profile %s (%s)", profileName, configFilePath())
+	}
+	keys, _ := config.FileKeys(configFilePath(), profileName)
+	setOrigin(keys, userOrigin)
+
+	// Layer the project file and environment variables over a copy, so they are never saved
+	settings := *stored
+	profile = &settings
+	// Named profiles share the presets of the default profile
+	profile.InheritPresets(cfg)
+	if wd, err := os.Getwd(); err == nil && profileErr == nil {
+		if path := config.FindProjectFile(wd); path != "" {
+			userPresets := profile.Presets
+			keys, err := profile.ApplyProjectFile(path)
+			if err != nil {
+				profileErr = err
+			}
+			for _, name := range profile.ShadowedPresets(userPresets) {
+				fmt.Fprintf(os.Stderr, "Warning: preset %s of %s replaces the preset of the same name in your config file\n", name, path)
+			}
+			setOrigin(keys, fmt.Sprintf("project (%s)", path))
+			Verbosef("Using project config %s\n", path)
+		}
+	}
+	profile.LoadFromEnv()
+	for key, env := range config.EnvKeys() {
+		settingOrigins[key] = fmt.Sprintf("env (%s)", env)
+	}
+}
+
+// setOrigin records origin for each config key
+func setOrigin(keys []string, origin string) {
+	for _, key := range keys {
+		settingOrigins[key] = origin
+	}
+}
+
+// settingOrigin describes where the effective value of a config key came from
+func settingOrigin(key string) string {
+	if origin, ok := settingOrigins[key]; ok {
+		return origin
+	}
+	return "default"
 }
 
-// GetConfig returns the loaded configuration
+// GetConfig returns the effective settings of the selected profile
 func GetConfig() *config.Config {
-	if cfg == nil {
+	if profile == nil {
 		cfg = config.New()
-		cfg.LoadFromEnv()
+		settings := *cfg
+		profile = &settings
+		profileName = config.DefaultProfile
+		profile.LoadFromEnv()
 	}
+	return profile
+}
+
+// configFile returns the loaded config file, with all profiles
+func configFile() *config.Config {
+	GetConfig()
 	return cfg
 }
 
-// GetAPIKey returns the API key from flags, env, or config
-func GetAPIKey(cmd *cobra.Command) string {
+// storedProfile returns the settings of the selected profile as saved in the
+// config file, without environment overrides
+func storedProfile() (*config.Config, error) {
+	return configFile().Profile(profileName)
+}
+
+// configFilePath returns the path of the config file
+func configFilePath() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	return config.GetDefaultConfigPath()
+}
+
+// apiKeyCommandTimeout bounds how long api_key_command may run
+const apiKeyCommandTimeout = 30 * time.Second
+
+// apiKeyCache holds the keys printed by api_key_command, so it runs at most
+// once per process
+var apiKeyCache = struct {
+	sync.Mutex
+	keys map[string]string
+}{keys: map[string]string{}}
+
+// GetAPIKey returns the API key from flags, env, api_key_command or the config
+// file, in that order. It returns errAPIKeyNotSet if none is configured.
+func GetAPIKey(cmd *cobra.Command) (string, error) {
 	// 1. Check command flag
 	if key, _ := cmd.Flags().GetString("api-key"); key != "" {
-		return key
+		return key, nil
 	}
 
-	// 2. Check env (already loaded in config)
-	// 3. Check config file
-	return GetConfig().APIKey
+	// 2. Check env
+	if key := os.Getenv(config.EnvAPIKey); key != "" {
+		return key, nil
+	}
+
+	// 3. Run the credential helper
+	if command := GetConfig().APIKeyCommand; command != "" {
+		return commandAPIKey(cmd.Context(), command)
+	}
+
+	// 4. Check config file, decrypting the key if needed
+	if key := GetConfig().APIKey; key != "" {
+		return key, nil
+	}
+	if GetConfig().IsAPIKeyLocked() {
+		return unlockAPIKey(GetConfig())
+	}
+	return "", errAPIKeyNotSet
+}
+
+// commandAPIKey returns the key printed by command, running it only once
+func commandAPIKey(ctx context.Context, command string) (string, error) {
+	apiKeyCache.Lock()
+	defer apiKeyCache.Unlock()
+
+	if key, ok := apiKeyCache.keys[command]; ok {
+		return key, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiKeyCommandTimeout)
+	defer cancel()
+	Verbosef("Running api_key_command\n")
+	key, err := config.RunAPIKeyCommand(ctx, command)
+	if err != nil {
+		return "", &ExitError{Code: ExitAuth, Err: err}
+	}
+	apiKeyCache.keys[command] = key
+	return key, nil
 }
 
 // GetEndpoint returns the API endpoint from flags, env, or config
@@ -100,6 +262,78 @@ func GetEndpoint(cmd *cobra.Command) string {
 	return GetConfig().GetEndpoint()
 }
 
+// NewAPIClient creates an API client configured from flags and config
+func NewAPIClient(cmd *cobra.Command, apiKey string) *api.Client {
+	return newAPIClientFor(cmd, apiKey, GetEndpoint(cmd))
+}
+
+// newAPIClientFor creates an API client for endpoint configured from flags and config
+func newAPIClientFor(cmd *cobra.Command, apiKey, endpoint string) *api.Client {
+	return api.NewClient(apiKey,
+		api.WithBaseURL(endpoint),
+		api.WithRetryPolicy(getRetryPolicy(cmd)),
+		api.WithRateLimit(getRateLimits(cmd)),
+	)
+}
+
+// getRateLimits builds the client-side rate limits from flags, falling back to config
+func getRateLimits(cmd *cobra.Command) api.RateLimits {
+	limits := api.RateLimits{
+		RequestsPerSecond: GetConfig().RateLimit,
+		PagesPerMinute:    getIntFlagOrConfig(cmd, "pages-per-minute", GetConfig().PagesPerMinute),
+	}
+	if cmd.Flags().Changed("rate-limit") {
+		limits.RequestsPerSecond, _ = cmd.Flags().GetFloat64("rate-limit")
+	}
+	return limits
+}
+
+// validateClientFlags checks the retry and throttling settings, from flags or
+// config, that Config.Set would reject. Commands that don't call the API skip
+// the check, so a bad value in the config file can still be fixed.
+func validateClientFlags(cmd *cobra.Command) error {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == configCmd || c == versionCmd || c == modelsCmd {
+			return nil
+		}
+	}
+	if getIntFlagOrConfig(cmd, "retry-max-wait", GetConfig().RetryMaxWait) < 1 {
+		return &ExitError{Code: ExitUsage, Err: config.ErrInvalidMaxWait}
+	}
+	limits := getRateLimits(cmd)
+	if limits.RequestsPerSecond < 0 {
+		return newExitError(ExitUsage, "rate-limit: %w", config.ErrInvalidNumber)
+	}
+	if limits.PagesPerMinute < 0 {
+		return newExitError(ExitUsage, "pages-per-minute: %w", config.ErrInvalidNumber)
+	}
+	return nil
+}
+
+// getRetryPolicy builds the retry policy from flags, falling back to config
+func getRetryPolicy(cmd *cobra.Command) api.RetryPolicy {
+	policy := api.DefaultRetryPolicy()
+	policy.MaxRetries = getIntFlagOrConfig(cmd, "retries", GetConfig().Retries)
+	policy.MaxDelay = time.Duration(getIntFlagOrConfig(cmd, "retry-max-wait", GetConfig().RetryMaxWait)) * time.Second
+	// A max wait below the base delay shortens the first delays, it never removes them
+	if policy.BaseDelay > policy.MaxDelay {
+		policy.BaseDelay = policy.MaxDelay
+	}
+	policy.OnRetry = func(attempt int, delay time.Duration, err error) {
+		Verbosef("Retry %d/%d in %s: %v\n", attempt, policy.MaxRetries, delay.Round(time.Millisecond), err)
+	}
+	return policy
+}
+
+// getIntFlagOrConfig returns the flag value if it was set explicitly, otherwise the config value
+func getIntFlagOrConfig(cmd *cobra.Command, flag string, configValue int) int {
+	if cmd.Flags().Changed(flag) {
+		value, _ := cmd.Flags().GetInt(flag)
+		return value
+	}
+	return configValue
+}
+
 // IsVerbose returns true if verbose mode is enabled
 func IsVerbose() bool {
 	return verbose
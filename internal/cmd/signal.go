@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// errInterrupted is the cancellation cause of the root context after SIGINT or SIGTERM
+var errInterrupted = errors.New("interrupted")
+
+// withInterrupt returns a context that is canceled with errInterrupted on the
+// first SIGINT or SIGTERM, so in-flight work can stop cleanly. A second signal
+// exits the process immediately. stop releases the signal handler.
+func withInterrupt(parent context.Context) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+		fmt.Fprintln(os.Stderr, "\nInterrupted, stopping (press Ctrl+C again to force quit)...")
+		cancel(errInterrupted)
+
+		select {
+		case <-signals:
+			fmt.Fprintln(os.Stderr, "\nForce quit")
+			os.Exit(ExitInterrupted)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel(nil)
+	}
+}
+
+// interrupted reports whether ctx was canceled by a signal
+func interrupted(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errInterrupted)
+}
@@ -1,8 +1,8 @@
 package cmd
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,27 +11,33 @@ import (
 )
 
 var statusCmd = &cobra.Command{
-	Use:   "status <request-id>",
+	Use:   "status <request-id|latest|file>",
 	Short: "Check async request status",
-	Long:  `Check the status of an asynchronous parse request.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runStatus,
+	Long: `Check the status of an asynchronous parse request.
+
+The request can be given by ID, as "latest" for the most recently submitted
+request, or as the path of a submitted file (see updoc jobs).`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStatus,
 }
 
 func init() {
 	statusCmd.Flags().BoolP("json", "j", false, "output as JSON")
 	statusCmd.Flags().BoolP("watch", "w", false, "watch status until completion")
-	statusCmd.Flags().IntP("interval", "i", 5, "watch interval in seconds")
+	statusCmd.Flags().IntP("interval", "i", 5, "minimum polling interval in seconds; polling adapts up to 30s")
 
 	rootCmd.AddCommand(statusCmd)
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
-	requestID := args[0]
+	apiKey, err := GetAPIKey(cmd)
+	if err != nil {
+		return err
+	}
 
-	apiKey := GetAPIKey(cmd)
-	if apiKey == "" {
-		return fmt.Errorf("API key not set")
+	requestID, err := resolveRequestID(args[0])
+	if err != nil {
+		return err
 	}
 
 	watch, _ := cmd.Flags().GetBool("watch")
@@ -43,12 +49,13 @@ func runStatus(cmd *cobra.Command, args []string) error {
 }
 
 func checkStatus(cmd *cobra.Command, apiKey, requestID string) error {
-	client := api.NewClient(apiKey, api.WithBaseURL(GetEndpoint(cmd)))
+	client := newJobClient(cmd, apiKey, requestID)
 
-	resp, err := client.GetStatus(context.Background(), requestID)
+	resp, err := client.GetStatus(cmd.Context(), requestID)
 	if err != nil {
 		return fmt.Errorf("failed to get status: %w", err)
 	}
+	updateJob(requestID, resp)
 
 	jsonOutput, _ := cmd.Flags().GetBool("json")
 	if jsonOutput {
@@ -62,45 +69,43 @@ func checkStatus(cmd *cobra.Command, apiKey, requestID string) error {
 }
 
 func watchStatus(cmd *cobra.Command, apiKey, requestID string) error {
-	client := api.NewClient(apiKey, api.WithBaseURL(GetEndpoint(cmd)))
+	client := newJobClient(cmd, apiKey, requestID)
 	interval, _ := cmd.Flags().GetInt("interval")
 
-	ticker := time.NewTicker(time.Duration(interval) * time.Second)
-	defer ticker.Stop()
-
-	// Check immediately first
-	resp, err := client.GetStatus(context.Background(), requestID)
-	if err != nil {
-		return fmt.Errorf("failed to get status: %w", err)
-	}
-	printStatus(resp)
-
-	if resp.Status == "completed" || resp.Status == "failed" {
-		return nil
-	}
-
-	fmt.Println("\nWatching for updates (Ctrl+C to stop)...")
-
-	for range ticker.C {
-		resp, err := client.GetStatus(context.Background(), requestID)
-		if err != nil {
-			return fmt.Errorf("failed to get status: %w", err)
-		}
-
-		// Clear previous output and print new status
-		fmt.Print("\033[2K\r") // Clear line
-		printStatusLine(resp)
-
-		if resp.Status == "completed" {
-			fmt.Println("\n\nCompleted! Get result with: updoc result", requestID)
+	checks := 0
+	_, err := client.WaitForStatus(cmd.Context(), requestID, api.WaitOptions{
+		Interval: time.Duration(interval) * time.Second,
+		OnStatus: func(resp *api.StatusResponse) {
+			checks++
+			updateJob(requestID, resp)
+
+			if checks == 1 {
+				printStatus(resp)
+				if resp.Status != api.StatusCompleted && resp.Status != api.StatusFailed {
+					fmt.Println("\nWatching for updates (Ctrl+C to stop)...")
+				}
+				return
+			}
+
+			// Clear previous output and print new status
+			fmt.Print("\033[2K\r") // Clear line
+			printStatusLine(resp)
+		},
+	})
+
+	var failed *api.RequestFailedError
+	switch {
+	case errors.As(err, &failed):
+		if checks == 1 {
 			return nil
 		}
-		if resp.Status == "failed" {
-			fmt.Println("\n\nRequest failed:", resp.Error)
-			return fmt.Errorf("request failed: %s", resp.Error)
-		}
+		fmt.Println("\n\nRequest failed:", failed.Message)
+		return newExitError(ExitAPI, "request failed: %s", failed.Message)
+	case err != nil:
+		return err
+	case checks > 1:
+		fmt.Println("\n\nCompleted! Get result with: updoc result", requestID)
 	}
-
 	return nil
 }
 
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/serithemage/updoc/internal/api"
+	"github.com/serithemage/updoc/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var tablesCmd = &cobra.Command{
+	Use:   "tables <file|result.json>",
+	Short: "Export document tables as CSV, TSV or XLSX",
+	Long: `Export the tables of a document as spreadsheet files.
+
+The document is parsed with the API, or read from a parse result previously
+saved with --json. Merged cells are expanded so every row has the same
+number of columns. CSV and TSV produce one file per table, named
+table-<page>-<id>; XLSX produces one workbook with a sheet per table.
+
+Examples:
+  # Parse a document and write its tables as CSV
+  updoc tables report.pdf -d ./tables/
+
+  # Export tables from a saved result as one workbook
+  updoc tables report.json --format xlsx
+
+  # Keep tables spanning several pages together
+  updoc tables report.pdf --merge-tables`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTables,
+}
+
+func init() {
+	tablesCmd.Flags().StringP("format", "f", output.TableFormatCSV, "export format: csv, tsv, xlsx")
+	tablesCmd.Flags().StringP("output-dir", "d", ".", "directory to write table files to")
+	addParseRequestFlags(tablesCmd)
+	addCacheFlags(tablesCmd)
+
+	rootCmd.AddCommand(tablesCmd)
+}
+
+func runTables(cmd *cobra.Command, args []string) error {
+	inputPath := args[0]
+
+	format, _ := cmd.Flags().GetString("format")
+	format = strings.ToLower(format)
+	if !slices.Contains(output.ValidTableFormats, format) {
+		return newExitError(ExitUsage, "invalid format %q: must be csv, tsv, or xlsx", format)
+	}
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+
+	var resp *api.ParseResponse
+	var err error
+	if isJSONFile(inputPath) {
+		resp, err = loadParseResponse(inputPath)
+	} else {
+		resp, err = parseForTables(cmd, inputPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	tables, err := output.ExtractTables(resp)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		Printf("No tables found in %s\n", filepath.Base(inputPath))
+		return nil
+	}
+
+	paths, err := output.WriteTables(tables, outputDir, fileStem(inputPath), format)
+	if err != nil {
+		return &ExitError{Code: ExitFileIO, Err: err}
+	}
+
+	Printf("Exported %d tables:\n", len(tables))
+	for _, path := range paths {
+		Printf("  %s\n", path)
+	}
+
+	return nil
+}
+
+// parseForTables parses a document with the API, always requesting HTML content
+func parseForTables(cmd *cobra.Command, filePath string) (*api.ParseResponse, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, &ExitError{Code: ExitFileIO, Err: fmt.Errorf("file not found: %s", filePath)}
+	}
+
+	apiKey, err := GetAPIKey(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewAPIClient(cmd, apiKey)
+	req := buildParseRequest(cmd, filePath)
+	req.OutputFormats = []string{api.OutputFormatHTML}
+
+	Printf("Parsing %s...\n", filepath.Base(filePath))
+
+	resp, err := parseDocument(cmd.Context(), cmd, client, req)
+	if err != nil {
+		return nil, fmt.Errorf("parse failed: %w", err)
+	}
+	return resp, nil
+}
@@ -6,6 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -16,6 +19,10 @@ const (
 	DefaultMode     = "standard"
 	DefaultOCR      = "auto"
 	DefaultEndpoint = "https://api.upstage.ai/v1"
+
+	DefaultRetries      = 3
+	DefaultRetryMaxWait = 30 // seconds
+	DefaultConcurrency  = 1
 )
 
 // Environment variable names
@@ -24,31 +31,56 @@ const (
 	EnvEndpoint   = "UPSTAGE_API_ENDPOINT"
 	EnvConfigPath = "UPDOC_CONFIG_PATH"
 	EnvLogLevel   = "UPDOC_LOG_LEVEL"
+	EnvProfile    = "UPDOC_PROFILE"
 )
 
 // Valid values
 var (
-	ValidFormats = []string{"html", "markdown", "text"}
+	ValidFormats = []string{"html", "markdown", "text", "json", "chunks"}
 	ValidModes   = []string{"standard", "enhanced", "auto"}
 	ValidOCRs    = []string{"auto", "force"}
 )
 
 // Errors
 var (
-	ErrUnknownKey    = errors.New("unknown configuration key")
-	ErrInvalidFormat = errors.New("invalid format: must be html, markdown, or text")
-	ErrInvalidMode   = errors.New("invalid mode: must be standard, enhanced, or auto")
-	ErrInvalidOCR    = errors.New("invalid ocr: must be auto or force")
+	ErrUnknownKey         = errors.New("unknown configuration key")
+	ErrInvalidFormat      = errors.New("invalid format: must be html, markdown, text, json, or chunks, or a comma-separated list of them")
+	ErrInvalidMode        = errors.New("invalid mode: must be standard, enhanced, or auto")
+	ErrInvalidOCR         = errors.New("invalid ocr: must be auto or force")
+	ErrInvalidNumber      = errors.New("invalid value: must be a non-negative number")
+	ErrInvalidConcurrency = errors.New("invalid concurrency: must be a positive integer")
+	ErrInvalidMaxWait     = errors.New("invalid retry-max-wait: must be at least 1 second")
+	ErrInvalidBool        = errors.New("invalid value: must be true or false")
 )
 
 // Config holds the application configuration
 type Config struct {
 	APIKey        string `yaml:"api_key"`
+	APIKeyCommand string `yaml:"api_key_command,omitempty"` // prints the API key, e.g. "pass show upstage"
 	Endpoint      string `yaml:"endpoint"`
 	DefaultFormat string `yaml:"default_format"`
 	DefaultMode   string `yaml:"default_mode"`
 	DefaultOCR    string `yaml:"default_ocr"`
 	OutputDir     string `yaml:"output_dir"`
+	MergeTables   bool   `yaml:"merge_tables"`
+	Retries       int    `yaml:"retries"`
+	RetryMaxWait  int    `yaml:"retry_max_wait"`
+	Concurrency   int    `yaml:"concurrency"`
+
+	// API key encrypted with a passphrase (see EncryptAPIKey); when set,
+	// APIKey holds the decrypted key and is not saved
+	EncryptedAPIKey string `yaml:"api_key_encrypted,omitempty"`
+
+	// Client-side throttling (0 = unlimited)
+	RateLimit      float64 `yaml:"rate_limit"`       // requests per second
+	PagesPerMinute int     `yaml:"pages_per_minute"` // estimated pages uploaded per minute
+
+	// Named sets of parse options, selected with parse --preset
+	Presets map[string]*Preset `yaml:"presets,omitempty"`
+
+	// Named profiles; the top-level settings are the default profile
+	ActiveProfile string   `yaml:"active_profile,omitempty"`
+	Profiles      Profiles `yaml:"profiles,omitempty"`
 }
 
 // New creates a new Config with default values
@@ -60,6 +92,9 @@ func New() *Config {
 		DefaultMode:   DefaultMode,
 		DefaultOCR:    DefaultOCR,
 		OutputDir:     "",
+		Retries:       DefaultRetries,
+		RetryMaxWait:  DefaultRetryMaxWait,
+		Concurrency:   DefaultConcurrency,
 	}
 }
 
@@ -68,6 +103,9 @@ func (c *Config) Set(key, value string) error {
 	switch key {
 	case "api-key":
 		c.APIKey = value
+		c.EncryptedAPIKey = ""
+	case "api-key-command":
+		c.APIKeyCommand = value
 	case "endpoint":
 		c.Endpoint = value
 	case "default-format":
@@ -87,6 +125,42 @@ func (c *Config) Set(key, value string) error {
 		c.DefaultOCR = value
 	case "output-dir":
 		c.OutputDir = value
+	case "merge-tables":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return ErrInvalidBool
+		}
+		c.MergeTables = b
+	case "retries":
+		n, err := parseNonNegativeInt(value)
+		if err != nil {
+			return err
+		}
+		c.Retries = n
+	case "retry-max-wait":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return ErrInvalidMaxWait
+		}
+		c.RetryMaxWait = n
+	case "concurrency":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return ErrInvalidConcurrency
+		}
+		c.Concurrency = n
+	case "rate-limit":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < 0 {
+			return ErrInvalidNumber
+		}
+		c.RateLimit = f
+	case "pages-per-minute":
+		n, err := parseNonNegativeInt(value)
+		if err != nil {
+			return err
+		}
+		c.PagesPerMinute = n
 	default:
 		return fmt.Errorf("%w: %s", ErrUnknownKey, key)
 	}
@@ -98,6 +172,8 @@ func (c *Config) Get(key string) (string, error) {
 	switch key {
 	case "api-key":
 		return c.APIKey, nil
+	case "api-key-command":
+		return c.APIKeyCommand, nil
 	case "endpoint":
 		return c.Endpoint, nil
 	case "default-format":
@@ -108,6 +184,18 @@ func (c *Config) Get(key string) (string, error) {
 		return c.DefaultOCR, nil
 	case "output-dir":
 		return c.OutputDir, nil
+	case "merge-tables":
+		return strconv.FormatBool(c.MergeTables), nil
+	case "retries":
+		return strconv.Itoa(c.Retries), nil
+	case "retry-max-wait":
+		return strconv.Itoa(c.RetryMaxWait), nil
+	case "concurrency":
+		return strconv.Itoa(c.Concurrency), nil
+	case "rate-limit":
+		return strconv.FormatFloat(c.RateLimit, 'f', -1, 64), nil
+	case "pages-per-minute":
+		return strconv.Itoa(c.PagesPerMinute), nil
 	default:
 		return "", fmt.Errorf("%w: %s", ErrUnknownKey, key)
 	}
@@ -116,11 +204,22 @@ func (c *Config) Get(key string) (string, error) {
 // Reset resets the configuration to default values
 func (c *Config) Reset() {
 	c.APIKey = ""
+	c.EncryptedAPIKey = ""
+	c.APIKeyCommand = ""
 	c.Endpoint = ""
 	c.DefaultFormat = DefaultFormat
 	c.DefaultMode = DefaultMode
 	c.DefaultOCR = DefaultOCR
 	c.OutputDir = ""
+	c.MergeTables = false
+	c.Retries = DefaultRetries
+	c.RetryMaxWait = DefaultRetryMaxWait
+	c.Concurrency = DefaultConcurrency
+	c.RateLimit = 0
+	c.PagesPerMinute = 0
+	c.Presets = nil
+	c.ActiveProfile = ""
+	c.Profiles = nil
 }
 
 // LoadFromEnv loads configuration from environment variables
@@ -141,6 +240,16 @@ func (c *Config) GetEndpoint() string {
 	return DefaultEndpoint
 }
 
+// MarshalYAML leaves out the plaintext API key when it is stored encrypted
+func (c Config) MarshalYAML() (interface{}, error) {
+	type plain Config
+	p := plain(c)
+	if p.EncryptedAPIKey != "" {
+		p.APIKey = ""
+	}
+	return p, nil
+}
+
 // SaveTo saves the configuration to a file
 func (c *Config) SaveTo(path string) error {
 	// Ensure directory exists
@@ -209,34 +318,50 @@ func GetDefaultConfigPath() string {
 	return filepath.Join(configDir, "updoc", "config.yaml")
 }
 
-// IsValidFormat checks if the format is valid
+// IsValidFormat checks if the format, or each format of a comma-separated list, is valid
 func IsValidFormat(format string) bool {
-	for _, v := range ValidFormats {
-		if v == format {
-			return true
+	formats := SplitFormats(format)
+	if len(formats) == 0 || len(formats) != len(strings.Split(format, ",")) {
+		return false
+	}
+	for _, f := range formats {
+		if !slices.Contains(ValidFormats, f) {
+			return false
 		}
 	}
-	return false
+	return true
 }
 
-// IsValidMode checks if the mode is valid
-func IsValidMode(mode string) bool {
-	for _, v := range ValidModes {
-		if v == mode {
-			return true
+// SplitFormats splits a comma-separated format list into trimmed, lowercase
+// formats, dropping empty entries and duplicates
+func SplitFormats(value string) []string {
+	var formats []string
+	for _, f := range strings.Split(value, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f != "" && !slices.Contains(formats, f) {
+			formats = append(formats, f)
 		}
 	}
-	return false
+	return formats
+}
+
+// IsValidMode checks if the mode is valid
+func IsValidMode(mode string) bool {
+	return slices.Contains(ValidModes, mode)
 }
 
 // IsValidOCR checks if the OCR setting is valid
 func IsValidOCR(ocr string) bool {
-	for _, v := range ValidOCRs {
-		if v == ocr {
-			return true
-		}
+	return slices.Contains(ValidOCRs, ocr)
+}
+
+// parseNonNegativeInt parses a count or duration (in seconds) setting
+func parseNonNegativeInt(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, ErrInvalidNumber
 	}
-	return false
+	return n, nil
 }
 
 // MaskAPIKey masks the API key for display
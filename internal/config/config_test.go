@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,6 +19,8 @@ func TestNewConfig(t *testing.T) {
 	assert.Equal(t, DefaultMode, cfg.DefaultMode)
 	assert.Equal(t, DefaultOCR, cfg.DefaultOCR)
 	assert.Equal(t, "", cfg.OutputDir)
+	assert.Equal(t, DefaultRetries, cfg.Retries)
+	assert.Equal(t, DefaultRetryMaxWait, cfg.RetryMaxWait)
 }
 
 func TestConfigDefaults(t *testing.T) {
@@ -92,6 +95,90 @@ func TestConfigSet(t *testing.T) {
 			getFunc:  func() string { return cfg.OutputDir },
 			wantErr:  false,
 		},
+		{
+			key:      "retries",
+			value:    "5",
+			expected: "5",
+			getFunc:  func() string { return strconv.Itoa(cfg.Retries) },
+			wantErr:  false,
+		},
+		{
+			key:      "retries",
+			value:    "-1",
+			expected: "5", // should remain unchanged
+			getFunc:  func() string { return strconv.Itoa(cfg.Retries) },
+			wantErr:  true,
+		},
+		{
+			key:      "retry-max-wait",
+			value:    "60",
+			expected: "60",
+			getFunc:  func() string { return strconv.Itoa(cfg.RetryMaxWait) },
+			wantErr:  false,
+		},
+		{
+			key:      "retry-max-wait",
+			value:    "soon",
+			expected: "60", // should remain unchanged
+			getFunc:  func() string { return strconv.Itoa(cfg.RetryMaxWait) },
+			wantErr:  true,
+		},
+		{
+			key:      "retry-max-wait",
+			value:    "0",
+			expected: "60", // should remain unchanged
+			getFunc:  func() string { return strconv.Itoa(cfg.RetryMaxWait) },
+			wantErr:  true,
+		},
+		{
+			key:      "concurrency",
+			value:    "4",
+			expected: "4",
+			getFunc:  func() string { return strconv.Itoa(cfg.Concurrency) },
+			wantErr:  false,
+		},
+		{
+			key:      "concurrency",
+			value:    "0",
+			expected: "4", // should remain unchanged
+			getFunc:  func() string { return strconv.Itoa(cfg.Concurrency) },
+			wantErr:  true,
+		},
+		{
+			key:      "rate-limit",
+			value:    "0.5",
+			expected: "0.5",
+			getFunc:  func() string { return strconv.FormatFloat(cfg.RateLimit, 'f', -1, 64) },
+			wantErr:  false,
+		},
+		{
+			key:      "rate-limit",
+			value:    "-2",
+			expected: "0.5", // should remain unchanged
+			getFunc:  func() string { return strconv.FormatFloat(cfg.RateLimit, 'f', -1, 64) },
+			wantErr:  true,
+		},
+		{
+			key:      "pages-per-minute",
+			value:    "100",
+			expected: "100",
+			getFunc:  func() string { return strconv.Itoa(cfg.PagesPerMinute) },
+			wantErr:  false,
+		},
+		{
+			key:      "merge-tables",
+			value:    "true",
+			expected: "true",
+			getFunc:  func() string { return strconv.FormatBool(cfg.MergeTables) },
+			wantErr:  false,
+		},
+		{
+			key:      "merge-tables",
+			value:    "sometimes",
+			expected: "true",
+			getFunc:  func() string { return strconv.FormatBool(cfg.MergeTables) },
+			wantErr:  true,
+		},
 		{
 			key:      "unknown-key",
 			value:    "value",
@@ -121,6 +208,7 @@ func TestConfigGet(t *testing.T) {
 	cfg.DefaultMode = "enhanced"
 	cfg.DefaultOCR = "force"
 	cfg.OutputDir = "/tmp"
+	cfg.Retries = 2
 
 	tests := []struct {
 		key      string
@@ -132,6 +220,12 @@ func TestConfigGet(t *testing.T) {
 		{"default-mode", "enhanced", false},
 		{"default-ocr", "force", false},
 		{"output-dir", "/tmp", false},
+		{"retries", "2", false},
+		{"retry-max-wait", "30", false},
+		{"concurrency", "1", false},
+		{"rate-limit", "0", false},
+		{"pages-per-minute", "0", false},
+		{"merge-tables", "false", false},
 		{"unknown", "", true},
 	}
 
@@ -156,7 +250,12 @@ func TestConfigValidateFormat(t *testing.T) {
 		{"html", true},
 		{"markdown", true},
 		{"text", true},
-		{"json", false},
+		{"chunks", true},
+		{"json", true},
+		{"markdown,json", true},
+		{"markdown, html ,json", true},
+		{"markdown,pdf", false},
+		{"markdown,", false},
 		{"pdf", false},
 		{"", false},
 	}
@@ -168,6 +267,12 @@ func TestConfigValidateFormat(t *testing.T) {
 	}
 }
 
+func TestSplitFormats(t *testing.T) {
+	assert.Equal(t, []string{"markdown"}, SplitFormats("markdown"))
+	assert.Equal(t, []string{"markdown", "html", "json"}, SplitFormats("markdown, HTML,json,markdown"))
+	assert.Empty(t, SplitFormats(" , "))
+}
+
 func TestConfigValidateMode(t *testing.T) {
 	tests := []struct {
 		mode    string
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ErrEmptyAPIKey is returned when the API key command prints no key
+var ErrEmptyAPIKey = errors.New("api_key_command printed no key")
+
+// RunAPIKeyCommand runs command with the system shell and returns the first
+// line of its output as the API key. The command can prompt on the terminal:
+// its stdin and stderr are those of updoc.
+func RunAPIKeyCommand(ctx context.Context, command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	// Don't wait for grandchildren still holding stdout after the timeout
+	cmd.WaitDelay = time.Second
+
+	var stdout bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("api_key_command did not finish: %w", ctx.Err())
+		}
+		return "", fmt.Errorf("api_key_command failed: %w", err)
+	}
+
+	key, _, _ := strings.Cut(stdout.String(), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", ErrEmptyAPIKey
+	}
+	return key, nil
+}
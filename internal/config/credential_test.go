@@ -0,0 +1,49 @@
+package config
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAPIKeyCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	ctx := context.Background()
+
+	key, err := RunAPIKeyCommand(ctx, `printf '  up_secret  \nsecond line\n'`)
+	require.NoError(t, err)
+	assert.Equal(t, "up_secret", key)
+
+	_, err = RunAPIKeyCommand(ctx, "exit 3")
+	assert.ErrorContains(t, err, "api_key_command failed")
+
+	_, err = RunAPIKeyCommand(ctx, "true")
+	assert.ErrorIs(t, err, ErrEmptyAPIKey)
+}
+
+func TestRunAPIKeyCommandTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := RunAPIKeyCommand(ctx, "sleep 2")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 1900*time.Millisecond)
+}
+
+func TestConfigSetAPIKeyCommand(t *testing.T) {
+	cfg := New()
+	require.NoError(t, cfg.Set("api-key-command", "pass show upstage"))
+	value, err := cfg.Get("api-key-command")
+	require.NoError(t, err)
+	assert.Equal(t, "pass show upstage", value)
+}
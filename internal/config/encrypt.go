@@ -0,0 +1,139 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// EnvPassphrase is the environment variable holding the passphrase of an
+// encrypted API key
+const EnvPassphrase = "UPDOC_PASSPHRASE"
+
+// encryptedKeyVersion prefixes encrypted API keys, identifying the KDF and cipher
+const encryptedKeyVersion = "v1"
+
+// scrypt parameters recommended for interactive logins
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32 // AES-256
+	saltLen      = 16
+)
+
+// Encryption errors
+var (
+	ErrWrongPassphrase  = errors.New("wrong passphrase or corrupted encrypted API key")
+	ErrEmptyPassphrase  = errors.New("passphrase must not be empty")
+	ErrInvalidEncrypted = errors.New("invalid encrypted API key")
+)
+
+// EncryptAPIKey encrypts key with AES-256-GCM under a key derived from
+// passphrase with scrypt. The result is "v1:" followed by the base64 of the
+// salt, nonce and ciphertext.
+func EncryptAPIKey(key, passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", ErrEmptyPassphrase
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data := append(salt, nonce...)
+	data = gcm.Seal(data, nonce, []byte(key), nil)
+	return encryptedKeyVersion + ":" + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecryptAPIKey decrypts a key encrypted with EncryptAPIKey
+func DecryptAPIKey(encrypted, passphrase string) (string, error) {
+	version, encoded, ok := strings.Cut(encrypted, ":")
+	if !ok || version != encryptedKeyVersion {
+		return "", ErrInvalidEncrypted
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < saltLen {
+		return "", ErrInvalidEncrypted
+	}
+
+	salt, data := data[:saltLen], data[saltLen:]
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", ErrInvalidEncrypted
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	key, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+	return string(key), nil
+}
+
+// newGCM returns the AES-GCM cipher for a passphrase and salt
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SetEncryptedAPIKey stores key encrypted with passphrase. The plaintext key
+// stays available in APIKey but is never saved.
+func (c *Config) SetEncryptedAPIKey(key, passphrase string) error {
+	encrypted, err := EncryptAPIKey(key, passphrase)
+	if err != nil {
+		return err
+	}
+	c.APIKey = key
+	c.EncryptedAPIKey = encrypted
+	return nil
+}
+
+// IsAPIKeyEncrypted reports whether the API key is stored encrypted
+func (c *Config) IsAPIKeyEncrypted() bool {
+	return c.EncryptedAPIKey != ""
+}
+
+// IsAPIKeyLocked reports whether the API key is encrypted and not yet decrypted
+func (c *Config) IsAPIKeyLocked() bool {
+	return c.EncryptedAPIKey != "" && c.APIKey == ""
+}
+
+// UnlockAPIKey decrypts the encrypted API key of this config only, leaving
+// the keys of other profiles, which may use other passphrases, encrypted
+func (c *Config) UnlockAPIKey(passphrase string) error {
+	if !c.IsAPIKeyLocked() {
+		return nil
+	}
+	key, err := DecryptAPIKey(c.EncryptedAPIKey, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt api_key: %w", err)
+	}
+	c.APIKey = key
+	return nil
+}
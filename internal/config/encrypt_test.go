@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptAPIKey(t *testing.T) {
+	encrypted, err := EncryptAPIKey("up_secret", "passphrase")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "v1:"))
+	assert.NotContains(t, encrypted, "up_secret")
+
+	// Each encryption uses a fresh salt and nonce
+	again, err := EncryptAPIKey("up_secret", "passphrase")
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again)
+
+	key, err := DecryptAPIKey(encrypted, "passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, "up_secret", key)
+
+	_, err = DecryptAPIKey(encrypted, "wrong")
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+
+	_, err = EncryptAPIKey("up_secret", "")
+	assert.ErrorIs(t, err, ErrEmptyPassphrase)
+}
+
+func TestDecryptAPIKeyInvalid(t *testing.T) {
+	tests := []string{"", "up_plain", "v2:AAAA", "v1:not base64!", "v1:AAAA"}
+	for _, encrypted := range tests {
+		_, err := DecryptAPIKey(encrypted, "passphrase")
+		assert.ErrorIs(t, err, ErrInvalidEncrypted, encrypted)
+	}
+}
+
+func TestEncryptedAPIKeySaveAndLoad(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	cfg := New()
+	require.NoError(t, cfg.SetEncryptedAPIKey("up_secret", "passphrase"))
+	assert.Equal(t, "up_secret", cfg.APIKey)
+	staging, err := cfg.CreateProfile("staging", nil)
+	require.NoError(t, err)
+	require.NoError(t, staging.SetEncryptedAPIKey("up_staging", "other passphrase"))
+	require.NoError(t, cfg.SaveTo(configPath))
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "up_secret")
+	assert.NotContains(t, string(data), "up_staging")
+	assert.Contains(t, string(data), "api_key_encrypted: v1:")
+
+	// Keys stay locked after loading, even with a passphrase in the environment
+	t.Setenv(EnvPassphrase, "other passphrase")
+	loaded, err := LoadFrom(configPath)
+	require.NoError(t, err)
+	assert.True(t, loaded.IsAPIKeyLocked())
+	assert.Equal(t, "", loaded.APIKey)
+
+	// Saving a locked config keeps the encrypted key
+	require.NoError(t, loaded.SaveTo(configPath))
+	loaded, err = LoadFrom(configPath)
+	require.NoError(t, err)
+
+	// Each profile is unlocked with its own passphrase
+	profile, err := loaded.Profile("staging")
+	require.NoError(t, err)
+	require.NoError(t, profile.UnlockAPIKey("other passphrase"))
+	assert.Equal(t, "up_staging", profile.APIKey)
+	assert.True(t, loaded.IsAPIKeyLocked())
+
+	assert.ErrorIs(t, loaded.UnlockAPIKey("other passphrase"), ErrWrongPassphrase)
+	require.NoError(t, loaded.UnlockAPIKey("passphrase"))
+	assert.Equal(t, "up_secret", loaded.APIKey)
+}
+
+func TestSetPlainAPIKeyReplacesEncrypted(t *testing.T) {
+	cfg := New()
+	require.NoError(t, cfg.SetEncryptedAPIKey("up_secret", "passphrase"))
+	require.NoError(t, cfg.Set("api-key", "up_plain"))
+	assert.False(t, cfg.IsAPIKeyEncrypted())
+	assert.Equal(t, "up_plain", cfg.APIKey)
+
+	require.NoError(t, cfg.SetEncryptedAPIKey("up_secret", "passphrase"))
+	cfg.Reset()
+	assert.False(t, cfg.IsAPIKeyEncrypted())
+}
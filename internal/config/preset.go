@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrPresetNotFound is returned for an undefined preset
+var ErrPresetNotFound = errors.New("preset not found")
+
+// Preset is a named set of parse options. Unset fields leave the option to
+// its flag, config or default value.
+type Preset struct {
+	// Parse request
+	Model            string   `yaml:"model,omitempty"`
+	Mode             string   `yaml:"mode,omitempty"`
+	OCR              string   `yaml:"ocr,omitempty"`
+	OutputFormats    []string `yaml:"output_formats,omitempty"`
+	ChartRecognition *bool    `yaml:"chart_recognition,omitempty"`
+	MergeTables      *bool    `yaml:"merge_tables,omitempty"`
+	Coordinates      *bool    `yaml:"coordinates,omitempty"`
+	Base64Categories []string `yaml:"base64_categories,omitempty"`
+
+	// Output
+	Format         string `yaml:"format,omitempty"`
+	OutputDir      string `yaml:"output_dir,omitempty"`
+	OutputTemplate string `yaml:"output_template,omitempty"`
+	ElementsOnly   *bool  `yaml:"elements_only,omitempty"`
+	ExtractImages  string `yaml:"extract_images,omitempty"`
+	TablesDir      string `yaml:"tables_dir,omitempty"`
+	TablesFormat   string `yaml:"tables_format,omitempty"`
+	ChunkSize      *int   `yaml:"chunk_size,omitempty"`
+	ChunkOverlap   *int   `yaml:"chunk_overlap,omitempty"`
+	ChunkUnit      string `yaml:"chunk_unit,omitempty"`
+}
+
+// PresetFlag is a preset option as the parse flag it stands for
+type PresetFlag struct {
+	Name  string // flag name, e.g. "merge-tables"
+	Value string // flag value, e.g. "true"
+}
+
+// Flags returns the options set by the preset as parse flags, in the order
+// of the Preset fields
+func (p *Preset) Flags() []PresetFlag {
+	var flags []PresetFlag
+	addString := func(name, value string) {
+		if value != "" {
+			flags = append(flags, PresetFlag{name, value})
+		}
+	}
+	addList := func(name string, values []string) {
+		if len(values) > 0 {
+			flags = append(flags, PresetFlag{name, strings.Join(values, ",")})
+		}
+	}
+	addBool := func(name string, value *bool) {
+		if value != nil {
+			flags = append(flags, PresetFlag{name, strconv.FormatBool(*value)})
+		}
+	}
+	addInt := func(name string, value *int) {
+		if value != nil {
+			flags = append(flags, PresetFlag{name, strconv.Itoa(*value)})
+		}
+	}
+
+	addString("model", p.Model)
+	addString("mode", p.Mode)
+	addString("ocr", p.OCR)
+	addList("output-formats", p.OutputFormats)
+	addBool("chart-recognition", p.ChartRecognition)
+	addBool("merge-tables", p.MergeTables)
+	addBool("coordinates", p.Coordinates)
+	addList("base64-categories", p.Base64Categories)
+
+	addString("format", p.Format)
+	addString("output-dir", p.OutputDir)
+	addString("output-template", p.OutputTemplate)
+	addBool("elements-only", p.ElementsOnly)
+	addString("extract-images", p.ExtractImages)
+	addString("tables-dir", p.TablesDir)
+	addString("tables-format", p.TablesFormat)
+	addInt("chunk-size", p.ChunkSize)
+	addInt("chunk-overlap", p.ChunkOverlap)
+	addString("chunk-unit", p.ChunkUnit)
+	return flags
+}
+
+// Validate checks the settings that are also validated by Set. The other
+// options are checked by the parse command like the flags they stand for.
+func (p *Preset) Validate() error {
+	if p.Mode != "" && !IsValidMode(p.Mode) {
+		return ErrInvalidMode
+	}
+	if p.OCR != "" && !IsValidOCR(p.OCR) {
+		return ErrInvalidOCR
+	}
+	if p.Format != "" && !IsValidFormat(p.Format) {
+		return ErrInvalidFormat
+	}
+	return nil
+}
+
+// nonLocalPath returns the first path option that may point outside the
+// working directory, as its YAML key and value
+func (p *Preset) nonLocalPath() (string, string) {
+	paths := []struct{ key, value string }{
+		{"output_dir", p.OutputDir},
+		{"output_template", p.OutputTemplate},
+		{"extract_images", p.ExtractImages},
+		{"tables_dir", p.TablesDir},
+	}
+	for _, path := range paths {
+		if path.value != "" && !filepath.IsLocal(path.value) {
+			return path.key, path.value
+		}
+	}
+	return "", ""
+}
+
+// Preset returns the named preset after validating it
+func (c *Config) Preset(name string) (*Preset, error) {
+	preset, ok := c.Presets[name]
+	if !ok || preset == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPresetNotFound, name)
+	}
+	if err := preset.Validate(); err != nil {
+		return nil, fmt.Errorf("preset %s: %w", name, err)
+	}
+	return preset, nil
+}
+
+// InheritPresets adds the presets of base that c does not define, e.g. the
+// presets of the default profile to a named profile. Presets of c win by name.
+func (c *Config) InheritPresets(base *Config) {
+	if base == nil || base == c || len(base.Presets) == 0 {
+		return
+	}
+	presets := maps.Clone(base.Presets)
+	maps.Copy(presets, c.Presets)
+	c.Presets = presets
+}
+
+// PresetNames returns the names of the defined presets in order
+func (c *Config) PresetNames() []string {
+	names := make([]string, 0, len(c.Presets))
+	for name := range c.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresetLoad(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := `presets:
+  scanned:
+    mode: enhanced
+    ocr: force
+    merge_tables: true
+    coordinates: false
+    format: markdown,html
+    output_formats: [html, markdown]
+    chunk_size: 512
+  quick:
+    model: document-parse-nightly
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(data), 0600))
+
+	cfg, err := LoadFrom(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"quick", "scanned"}, cfg.PresetNames())
+
+	scanned, err := cfg.Preset("scanned")
+	require.NoError(t, err)
+	assert.Equal(t, []PresetFlag{
+		{"mode", "enhanced"},
+		{"ocr", "force"},
+		{"output-formats", "html,markdown"},
+		{"merge-tables", "true"},
+		{"coordinates", "false"},
+		{"format", "markdown,html"},
+		{"chunk-size", "512"},
+	}, scanned.Flags())
+
+	_, err = cfg.Preset("missing")
+	assert.ErrorIs(t, err, ErrPresetNotFound)
+}
+
+func TestPresetValidate(t *testing.T) {
+	cfg := New()
+	cfg.Presets = map[string]*Preset{
+		"mode":   {Mode: "fast"},
+		"ocr":    {OCR: "never"},
+		"format": {Format: "pdf"},
+	}
+
+	_, err := cfg.Preset("mode")
+	assert.ErrorIs(t, err, ErrInvalidMode)
+	_, err = cfg.Preset("ocr")
+	assert.ErrorIs(t, err, ErrInvalidOCR)
+	_, err = cfg.Preset("format")
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func TestInheritPresets(t *testing.T) {
+	cfg := New()
+	cfg.Presets = map[string]*Preset{"scanned": {Mode: "enhanced"}, "quick": {Mode: "standard"}}
+	staging, err := cfg.CreateProfile("staging", nil)
+	require.NoError(t, err)
+	staging.Presets = map[string]*Preset{"quick": {Mode: "auto"}}
+
+	settings := *staging
+	settings.InheritPresets(cfg)
+	assert.Equal(t, []string{"quick", "scanned"}, settings.PresetNames())
+	assert.Equal(t, "enhanced", settings.Presets["scanned"].Mode)
+	// The profile's own preset wins by name
+	assert.Equal(t, "auto", settings.Presets["quick"].Mode)
+	// The stored profile is unchanged, so saving it does not copy presets
+	assert.Equal(t, []string{"quick"}, staging.PresetNames())
+
+	// The default profile inherits from nothing
+	cfg.InheritPresets(cfg)
+	assert.Len(t, cfg.Presets, 2)
+}
+
+func TestApplyProjectFilePresets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ProjectFileName)
+	require.NoError(t, os.WriteFile(path, []byte("presets:\n  scanned:\n    ocr: force\n"), 0644))
+
+	user := New()
+	user.Presets = map[string]*Preset{"quick": {Mode: "standard"}, "scanned": {Mode: "enhanced"}}
+	settings := *user
+
+	_, err := settings.ApplyProjectFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"quick", "scanned"}, settings.PresetNames())
+	// A project preset replaces the user preset of the same name
+	assert.Equal(t, &Preset{OCR: "force"}, settings.Presets["scanned"])
+	assert.Equal(t, []string{"scanned"}, settings.ShadowedPresets(user.Presets))
+
+	// The user config is unchanged, so saving it does not pick up project presets
+	assert.Equal(t, "enhanced", user.Presets["scanned"].Mode)
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is the name of the top-level settings of the config file
+const DefaultProfile = "default"
+
+// Profile errors
+var (
+	ErrProfileNotFound    = errors.New("profile not found")
+	ErrProfileExists      = errors.New("profile already exists")
+	ErrInvalidProfileName = errors.New("invalid profile name: use letters, digits, '-' and '_'")
+)
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Profiles maps profile names to their settings. Settings a profile does not
+// set take their default values, not those of the default profile.
+type Profiles map[string]*Config
+
+// UnmarshalYAML decodes each profile on top of the default settings
+func (p *Profiles) UnmarshalYAML(value *yaml.Node) error {
+	var nodes map[string]yaml.Node
+	if err := value.Decode(&nodes); err != nil {
+		return err
+	}
+
+	profiles := make(Profiles, len(nodes))
+	for name, node := range nodes {
+		profile := New()
+		if err := node.Decode(profile); err != nil {
+			return fmt.Errorf("profile %s: %w", name, err)
+		}
+		// Profiles do not nest
+		profile.ActiveProfile = ""
+		profile.Profiles = nil
+		profiles[name] = profile
+	}
+	*p = profiles
+	return nil
+}
+
+// ProfileName returns the name of the profile to use: name if set, then
+// $UPDOC_PROFILE, then the profile selected with UseProfile
+func (c *Config) ProfileName(name string) string {
+	if name != "" {
+		return name
+	}
+	if env := os.Getenv(EnvProfile); env != "" {
+		return env
+	}
+	if c.ActiveProfile != "" {
+		return c.ActiveProfile
+	}
+	return DefaultProfile
+}
+
+// Profile returns the settings of the named profile. The default profile is
+// the config itself.
+func (c *Config) Profile(name string) (*Config, error) {
+	if name == "" || name == DefaultProfile {
+		return c, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	return profile, nil
+}
+
+// ProfileNames returns the default profile followed by the named profiles in order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles)+1)
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...)
+}
+
+// CreateProfile adds a named profile with the settings of from, or with
+// default settings if from is nil
+func (c *Config) CreateProfile(name string, from *Config) (*Config, error) {
+	if !profileNamePattern.MatchString(name) {
+		return nil, ErrInvalidProfileName
+	}
+	if _, err := c.Profile(name); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrProfileExists, name)
+	}
+
+	profile := New()
+	if from != nil {
+		*profile = *from
+		profile.ActiveProfile = ""
+		profile.Profiles = nil
+	}
+	if c.Profiles == nil {
+		c.Profiles = make(Profiles)
+	}
+	c.Profiles[name] = profile
+	return profile, nil
+}
+
+// DeleteProfile removes a named profile. If it was selected, the default
+// profile is selected again.
+func (c *Config) DeleteProfile(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("cannot delete the %s profile", DefaultProfile)
+	}
+	if _, ok := c.Profiles[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	delete(c.Profiles, name)
+	if c.ActiveProfile == name {
+		c.ActiveProfile = ""
+	}
+	return nil
+}
+
+// ResetSettings resets the settings of a profile to their defaults, keeping
+// the named profiles and the selected profile
+func (c *Config) ResetSettings() {
+	activeProfile, profiles := c.ActiveProfile, c.Profiles
+	c.Reset()
+	c.ActiveProfile, c.Profiles = activeProfile, profiles
+}
+
+// UseProfile selects the profile used when none is given explicitly
+func (c *Config) UseProfile(name string) error {
+	if _, err := c.Profile(name); err != nil {
+		return err
+	}
+	c.ActiveProfile = name
+	if name == DefaultProfile {
+		c.ActiveProfile = ""
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileLoadFlatConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("api_key: flat-key\ndefault_format: html\n"), 0600))
+
+	cfg, err := LoadFrom(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{DefaultProfile}, cfg.ProfileNames())
+
+	profile, err := cfg.Profile(DefaultProfile)
+	require.NoError(t, err)
+	assert.Same(t, cfg, profile)
+	assert.Equal(t, "flat-key", profile.APIKey)
+	assert.Equal(t, "html", profile.DefaultFormat)
+}
+
+func TestProfileLoad(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := `api_key: public-key
+default_format: html
+active_profile: private
+profiles:
+  private:
+    api_key: private-key
+    endpoint: https://upstage.internal/v1
+  staging:
+    api_key: staging-key
+    retries: 0
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(data), 0600))
+
+	cfg, err := LoadFrom(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "private", "staging"}, cfg.ProfileNames())
+	assert.Equal(t, "private", cfg.ProfileName(""))
+
+	private, err := cfg.Profile("private")
+	require.NoError(t, err)
+	assert.Equal(t, "private-key", private.APIKey)
+	assert.Equal(t, "https://upstage.internal/v1", private.GetEndpoint())
+	// Unset settings take their defaults, not the default profile's values
+	assert.Equal(t, DefaultFormat, private.DefaultFormat)
+	assert.Equal(t, DefaultRetries, private.Retries)
+
+	staging, err := cfg.Profile("staging")
+	require.NoError(t, err)
+	assert.Equal(t, 0, staging.Retries)
+
+	_, err = cfg.Profile("missing")
+	assert.ErrorIs(t, err, ErrProfileNotFound)
+}
+
+func TestProfileName(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	cfg := New()
+	assert.Equal(t, DefaultProfile, cfg.ProfileName(""))
+
+	cfg.ActiveProfile = "private"
+	assert.Equal(t, "private", cfg.ProfileName(""))
+
+	t.Setenv(EnvProfile, "staging")
+	assert.Equal(t, "staging", cfg.ProfileName(""))
+	assert.Equal(t, "flag", cfg.ProfileName("flag"))
+}
+
+func TestProfileCreateUseDelete(t *testing.T) {
+	cfg := New()
+	cfg.APIKey = "public-key"
+
+	staging, err := cfg.CreateProfile("staging", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "", staging.APIKey)
+
+	copied, err := cfg.CreateProfile("copy", cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "public-key", copied.APIKey)
+	assert.Empty(t, copied.Profiles)
+
+	_, err = cfg.CreateProfile("staging", nil)
+	assert.ErrorIs(t, err, ErrProfileExists)
+	_, err = cfg.CreateProfile(DefaultProfile, nil)
+	assert.ErrorIs(t, err, ErrProfileExists)
+	_, err = cfg.CreateProfile("bad name", nil)
+	assert.ErrorIs(t, err, ErrInvalidProfileName)
+
+	require.NoError(t, cfg.UseProfile("staging"))
+	assert.Equal(t, "staging", cfg.ActiveProfile)
+	assert.ErrorIs(t, cfg.UseProfile("missing"), ErrProfileNotFound)
+
+	require.NoError(t, cfg.DeleteProfile("staging"))
+	assert.Equal(t, "", cfg.ActiveProfile)
+	assert.ErrorIs(t, cfg.DeleteProfile("staging"), ErrProfileNotFound)
+	assert.Error(t, cfg.DeleteProfile(DefaultProfile))
+
+	require.NoError(t, cfg.UseProfile("copy"))
+	require.NoError(t, cfg.UseProfile(DefaultProfile))
+	assert.Equal(t, "", cfg.ActiveProfile)
+}
+
+func TestProfileResetSettings(t *testing.T) {
+	cfg := New()
+	cfg.DefaultFormat = "html"
+	staging, err := cfg.CreateProfile("staging", nil)
+	require.NoError(t, err)
+	staging.DefaultMode = "enhanced"
+	require.NoError(t, cfg.UseProfile("staging"))
+
+	staging.ResetSettings()
+	assert.Equal(t, DefaultMode, staging.DefaultMode)
+	assert.Equal(t, "html", cfg.DefaultFormat)
+
+	cfg.ResetSettings()
+	assert.Equal(t, DefaultFormat, cfg.DefaultFormat)
+	assert.Equal(t, "staging", cfg.ActiveProfile)
+	assert.Equal(t, []string{"default", "staging"}, cfg.ProfileNames())
+}
+
+func TestProfileSaveAndLoad(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	cfg := New()
+	cfg.APIKey = "public-key"
+	staging, err := cfg.CreateProfile("staging", nil)
+	require.NoError(t, err)
+	require.NoError(t, staging.Set("api-key", "staging-key"))
+	require.NoError(t, cfg.UseProfile("staging"))
+	require.NoError(t, cfg.SaveTo(configPath))
+
+	loaded, err := LoadFrom(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "public-key", loaded.APIKey)
+	assert.Equal(t, "staging", loaded.ActiveProfile)
+
+	profile, err := loaded.Profile("staging")
+	require.NoError(t, err)
+	assert.Equal(t, "staging-key", profile.APIKey)
+}
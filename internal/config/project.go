@@ -0,0 +1,184 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the name of the project config file searched for from
+// the working directory upward
+const ProjectFileName = ".updoc.yaml"
+
+// projectForbiddenKeys may only be set in the user config file. Project files
+// are usually committed, so they must not hold secrets, run commands or send
+// the user's API key to another endpoint.
+var projectForbiddenKeys = []string{"api_key", "api_key_encrypted", "api_key_command", "endpoint", "active_profile", "profiles"}
+
+// ErrForbiddenProjectKey is returned for settings not allowed in a project file
+var ErrForbiddenProjectKey = errors.New("not allowed in a project config file")
+
+// FindProjectFile returns the path of the nearest project config file in dir
+// or its parents, or "" if there is none
+func FindProjectFile(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ApplyProjectFile overlays the settings of a project config file onto c and
+// returns the config keys it set, e.g. "default-format"
+func (c *Config) ApplyProjectFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project config file: %w", err)
+	}
+
+	keys, err := settingKeys(data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, key := range projectForbiddenKeys {
+		if slices.Contains(keys, yamlKeyToConfigKey(key)) {
+			return nil, fmt.Errorf("%s: %s is %w; set it with 'updoc config set' instead", path, key, ErrForbiddenProjectKey)
+		}
+	}
+
+	// Output must stay inside the project
+	var project struct {
+		OutputDir string             `yaml:"output_dir"`
+		Presets   map[string]*Preset `yaml:"presets"`
+	}
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if project.OutputDir != "" && !filepath.IsLocal(project.OutputDir) {
+		return nil, fmt.Errorf("%s: output_dir %q is %w: use a relative path inside the project", path, project.OutputDir, ErrForbiddenProjectKey)
+	}
+	for _, name := range slices.Sorted(maps.Keys(project.Presets)) {
+		if preset := project.Presets[name]; preset != nil {
+			if field, value := preset.nonLocalPath(); field != "" {
+				return nil, fmt.Errorf("%s: %s %q of preset %s is %w: use a relative path inside the project", path, field, value, name, ErrForbiddenProjectKey)
+			}
+		}
+	}
+
+	// Presets of the project file are added to a copy, so the map shared with
+	// the user config is left alone
+	c.Presets = maps.Clone(c.Presets)
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// ShadowedPresets returns the names of the presets in user that c defines
+// differently, e.g. after a project file replaced them
+func (c *Config) ShadowedPresets(user map[string]*Preset) []string {
+	var names []string
+	for name, preset := range user {
+		if current, ok := c.Presets[name]; ok && current != preset {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FileKeys returns the config keys set for a profile in a config file. Keys
+// of the default profile are the top-level settings.
+func FileKeys(path, profile string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var within []string
+	if profile != "" && profile != DefaultProfile {
+		within = []string{"profiles", profile}
+	}
+	return settingKeys(data, within)
+}
+
+// settingKeys returns the config keys set in the YAML mapping found by
+// following the path of keys in within
+func settingKeys(data []byte, within []string) ([]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	node := doc.Content[0]
+	for _, name := range within {
+		node = mappingValue(node, name)
+		if node == nil {
+			return nil, nil
+		}
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, errors.New("expected a mapping of settings")
+	}
+
+	var keys []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, yamlKeyToConfigKey(node.Content[i].Value))
+	}
+	return keys, nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlKeyToConfigKey turns a config file key such as default_format into the
+// key used by Get and Set
+func yamlKeyToConfigKey(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// EnvKeys returns the config keys overridden by environment variables, mapped
+// to the variable that sets them
+func EnvKeys() map[string]string {
+	keys := map[string]string{}
+	if os.Getenv(EnvAPIKey) != "" {
+		keys["api-key"] = EnvAPIKey
+	}
+	if os.Getenv(EnvEndpoint) != "" {
+		keys["endpoint"] = EnvEndpoint
+	}
+	return keys
+}
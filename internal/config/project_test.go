@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProjectFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	assert.Equal(t, "", FindProjectFile(nested))
+
+	path := filepath.Join(root, ProjectFileName)
+	require.NoError(t, os.WriteFile(path, []byte("default_mode: enhanced\n"), 0644))
+	assert.Equal(t, path, FindProjectFile(nested))
+	assert.Equal(t, path, FindProjectFile(root))
+
+	closer := filepath.Join(root, "a", ProjectFileName)
+	require.NoError(t, os.WriteFile(closer, []byte("default_mode: auto\n"), 0644))
+	assert.Equal(t, closer, FindProjectFile(nested))
+}
+
+func TestApplyProjectFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ProjectFileName)
+	require.NoError(t, os.WriteFile(path, []byte("default_mode: enhanced\nmerge_tables: true\n"), 0644))
+
+	cfg := New()
+	cfg.APIKey = "user-key"
+	cfg.DefaultFormat = "html"
+
+	keys, err := cfg.ApplyProjectFile(path)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"default-mode", "merge-tables"}, keys)
+	assert.Equal(t, "enhanced", cfg.DefaultMode)
+	assert.True(t, cfg.MergeTables)
+	// Settings the project file does not set are kept
+	assert.Equal(t, "user-key", cfg.APIKey)
+	assert.Equal(t, "html", cfg.DefaultFormat)
+}
+
+func TestApplyProjectFileForbidsSecrets(t *testing.T) {
+	for _, data := range []string{
+		"api_key: secret\n",
+		"api_key_command: pass show upstage\n",
+		"api_key_encrypted: v1:AAAA\n",
+		"endpoint: http://attacker.example.com/v1\n",
+		"output_dir: /home/me/.ssh\n",
+		"output_dir: ../../outside\n",
+		"presets:\n  p:\n    output_dir: /tmp/out\n",
+		"presets:\n  p:\n    output_dir: ../outside\n",
+		"presets:\n  p:\n    output_template: /home/me/.bashrc\n",
+		"presets:\n  p:\n    output_template: \"../{stem}.md\"\n",
+		"presets:\n  p:\n    extract_images: /var/www/images\n",
+		"presets:\n  p:\n    tables_dir: ../../tables\n",
+		"profiles:\n  staging:\n    api_key: secret\n",
+		"active_profile: staging\n",
+	} {
+		path := filepath.Join(t.TempDir(), ProjectFileName)
+		require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+
+		cfg := New()
+		_, err := cfg.ApplyProjectFile(path)
+		assert.ErrorIs(t, err, ErrForbiddenProjectKey, data)
+		assert.Equal(t, "", cfg.APIKey)
+		assert.Equal(t, DefaultEndpoint, cfg.GetEndpoint())
+		assert.Equal(t, "", cfg.OutputDir)
+		assert.Empty(t, cfg.Presets)
+	}
+
+	// Relative output directories inside the project are allowed
+	path := filepath.Join(t.TempDir(), ProjectFileName)
+	data := "output_dir: build/docs\npresets:\n  p:\n    output_dir: out\n    output_template: \"{yyyy}/{stem}.md\"\n    tables_dir: out/tables\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	cfg := New()
+	_, err := cfg.ApplyProjectFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "build/docs", cfg.OutputDir)
+	assert.Equal(t, "{yyyy}/{stem}.md", cfg.Presets["p"].OutputTemplate)
+}
+
+func TestFileKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "api_key: key\ndefault_format: html\nprofiles:\n  staging:\n    endpoint: https://staging.example.com\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+	keys, err := FileKeys(path, DefaultProfile)
+	require.NoError(t, err)
+	assert.Subset(t, keys, []string{"api-key", "default-format"})
+
+	keys, err = FileKeys(path, "staging")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"endpoint"}, keys)
+
+	keys, err = FileKeys(path, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	keys, err = FileKeys(filepath.Join(t.TempDir(), "missing.yaml"), DefaultProfile)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestEnvKeys(t *testing.T) {
+	t.Setenv(EnvAPIKey, "")
+	t.Setenv(EnvEndpoint, "https://env.example.com")
+	assert.Equal(t, map[string]string{"endpoint": EnvEndpoint}, EnvKeys())
+}
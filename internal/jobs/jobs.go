@@ -0,0 +1,270 @@
+// Package jobs records submitted async parse requests so they can be found again.
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/serithemage/updoc/internal/api"
+)
+
+// EnvJobsDir overrides the default jobs directory
+const EnvJobsDir = "UPDOC_JOBS_DIR"
+
+// jobExt is the file extension of job records
+const jobExt = ".json"
+
+// ErrNotFound is returned when no recorded job matches
+var ErrNotFound = errors.New("job not found")
+
+// Options are the parse options a job was submitted with
+type Options struct {
+	Model            string   `json:"model"`
+	Mode             string   `json:"mode"`
+	OCR              string   `json:"ocr"`
+	ChartRecognition bool     `json:"chart_recognition"`
+	MergeTables      bool     `json:"merge_tables"`
+	Coordinates      bool     `json:"coordinates"`
+	OutputFormats    []string `json:"output_formats,omitempty"`
+	Base64Categories []string `json:"base64_categories,omitempty"`
+}
+
+// Job is a submitted async request and its last known status
+type Job struct {
+	RequestID      string    `json:"request_id"`
+	Source         string    `json:"source"`
+	Options        Options   `json:"options"`
+	Endpoint       string    `json:"endpoint"`
+	SubmittedAt    time.Time `json:"submitted_at"`
+	Status         string    `json:"status"`
+	Progress       int       `json:"progress,omitempty"`
+	PagesProcessed int       `json:"pages_processed,omitempty"`
+	TotalPages     int       `json:"total_pages,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	CheckedAt      time.Time `json:"checked_at,omitempty"`
+}
+
+// New returns a job for a request just submitted to endpoint
+func New(requestID string, req *api.ParseRequest, endpoint string) *Job {
+	source := req.FilePath
+	if abs, err := filepath.Abs(source); err == nil {
+		source = abs
+	}
+
+	return &Job{
+		RequestID: requestID,
+		Source:    source,
+		Options: Options{
+			Model:            req.Model,
+			Mode:             req.Mode,
+			OCR:              req.OCR,
+			ChartRecognition: req.ChartRecognition,
+			MergeTables:      req.MergeTables,
+			Coordinates:      req.Coordinates,
+			OutputFormats:    req.OutputFormats,
+			Base64Categories: req.Base64Categories,
+		},
+		Endpoint:    endpoint,
+		SubmittedAt: time.Now(),
+		Status:      "pending",
+	}
+}
+
+// Update records a status received from the API
+func (j *Job) Update(status *api.StatusResponse) {
+	j.Status = status.Status
+	j.Progress = status.Progress
+	j.PagesProcessed = status.PagesProcessed
+	j.TotalPages = status.TotalPages
+	j.Error = status.Error
+	j.CheckedAt = time.Now()
+}
+
+// Finished reports whether the job has completed or failed
+func (j *Job) Finished() bool {
+	return j.Status == "completed" || j.Status == "failed"
+}
+
+// Store is a directory of job records, one file per request ID
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir returns the default jobs directory under the user cache dir
+func DefaultDir() (string, error) {
+	if dir := os.Getenv(EnvJobsDir); dir != "" {
+		return dir, nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	return filepath.Join(base, "updoc", "jobs"), nil
+}
+
+// Dir returns the store directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) path(requestID string) (string, error) {
+	if requestID == "" || requestID == "." || requestID == ".." || strings.ContainsAny(requestID, `/\`) {
+		return "", fmt.Errorf("invalid request ID %q", requestID)
+	}
+	return filepath.Join(s.dir, requestID+jobExt), nil
+}
+
+// Save writes job, replacing any previous record with the same request ID
+func (s *Store) Save(job *Job) error {
+	path, err := s.path(job.RequestID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create jobs directory: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent readers never see a partial record
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write job: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write job: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write job: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write job: %w", err)
+	}
+	return nil
+}
+
+// Get returns the job with requestID, or ErrNotFound
+func (s *Store) Get(requestID string) (*Job, error) {
+	path, err := s.path(requestID)
+	if err != nil {
+		return nil, err
+	}
+	return readJob(path)
+}
+
+func readJob(path string) (*Job, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job: %w", err)
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("invalid job record %s: %w", path, err)
+	}
+	return &job, nil
+}
+
+// List returns all jobs, most recently submitted first.
+// Unreadable records are skipped.
+func (s *Store) List() ([]*Job, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs: %w", err)
+	}
+
+	var jobs []*Job
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || !strings.HasSuffix(entry.Name(), jobExt) {
+			continue
+		}
+		job, err := readJob(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	sort.SliceStable(jobs, func(i, k int) bool {
+		return jobs[i].SubmittedAt.After(jobs[k].SubmittedAt)
+	})
+	return jobs, nil
+}
+
+// Latest returns the most recently submitted job, or ErrNotFound
+func (s *Store) Latest() (*Job, error) {
+	jobs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, ErrNotFound
+	}
+	return jobs[0], nil
+}
+
+// FindBySource returns the most recently submitted job for the source file, or ErrNotFound
+func (s *Store) FindBySource(source string) (*Job, error) {
+	if abs, err := filepath.Abs(source); err == nil {
+		source = abs
+	}
+
+	jobs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.Source == source {
+			return job, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Prune removes jobs submitted before cutoff and returns how many were removed
+func (s *Store) Prune(cutoff time.Time) (int, error) {
+	jobs, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, job := range jobs {
+		if !job.SubmittedAt.Before(cutoff) {
+			continue
+		}
+		path, err := s.path(job.RequestID)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, fmt.Errorf("failed to remove job: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
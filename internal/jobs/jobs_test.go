@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/serithemage/updoc/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saveJob(t *testing.T, s *Store, id, source string, submitted time.Time) *Job {
+	t.Helper()
+	job := New(id, api.NewParseRequest(source), "https://example.com/v1")
+	job.SubmittedAt = submitted
+	require.NoError(t, s.Save(job))
+	return job
+}
+
+func TestNew(t *testing.T) {
+	req := api.NewParseRequest("doc.pdf")
+	req.Mode = "enhanced"
+	req.OutputFormats = []string{"markdown"}
+
+	job := New("req-1", req, "https://example.com/v1")
+	assert.Equal(t, "req-1", job.RequestID)
+	assert.True(t, filepath.IsAbs(job.Source))
+	assert.Equal(t, "enhanced", job.Options.Mode)
+	assert.Equal(t, []string{"markdown"}, job.Options.OutputFormats)
+	assert.Equal(t, "https://example.com/v1", job.Endpoint)
+	assert.Equal(t, "pending", job.Status)
+	assert.False(t, job.Finished())
+
+	job.Update(&api.StatusResponse{Status: "completed", Progress: 100, PagesProcessed: 3, TotalPages: 3})
+	assert.True(t, job.Finished())
+	assert.Equal(t, 3, job.TotalPages)
+	assert.False(t, job.CheckedAt.IsZero())
+}
+
+func TestStoreSaveGet(t *testing.T) {
+	s := NewStore(t.TempDir())
+	saved := saveJob(t, s, "req-1", "/docs/a.pdf", time.Now())
+
+	job, err := s.Get("req-1")
+	require.NoError(t, err)
+	assert.Equal(t, saved.Source, job.Source)
+	assert.Equal(t, saved.Endpoint, job.Endpoint)
+
+	_, err = s.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = s.Get("../escape")
+	assert.Error(t, err)
+}
+
+func TestStoreListLatestFind(t *testing.T) {
+	s := NewStore(t.TempDir())
+	now := time.Now()
+	saveJob(t, s, "old", "/docs/a.pdf", now.Add(-2*time.Hour))
+	saveJob(t, s, "newer", "/docs/a.pdf", now.Add(-time.Hour))
+	saveJob(t, s, "newest", "/docs/b.pdf", now)
+
+	jobs, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, jobs, 3)
+	assert.Equal(t, "newest", jobs[0].RequestID)
+	assert.Equal(t, "old", jobs[2].RequestID)
+
+	latest, err := s.Latest()
+	require.NoError(t, err)
+	assert.Equal(t, "newest", latest.RequestID)
+
+	job, err := s.FindBySource("/docs/a.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, "newer", job.RequestID)
+
+	_, err = s.FindBySource("/docs/c.pdf")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStoreEmpty(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "missing"))
+
+	jobs, err := s.List()
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	_, err = s.Latest()
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStorePrune(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	now := time.Now()
+	saveJob(t, s, "old", "/docs/a.pdf", now.Add(-48*time.Hour))
+	saveJob(t, s, "new", "/docs/b.pdf", now)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600))
+
+	removed, err := s.Prune(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	jobs, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "new", jobs[0].RequestID)
+}
@@ -0,0 +1,303 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/serithemage/updoc/internal/api"
+)
+
+// Chunking defaults
+const (
+	DefaultChunkSize    = 2000
+	DefaultChunkOverlap = 200
+)
+
+// Chunk size units
+const (
+	ChunkUnitChars  = "chars"
+	ChunkUnitTokens = "tokens"
+)
+
+// Chunk is a retrieval-sized piece of a document
+type Chunk struct {
+	ID         int      `json:"id"`
+	Text       string   `json:"text"`
+	Headings   []string `json:"headings"`
+	PageStart  int      `json:"page_start"`
+	PageEnd    int      `json:"page_end"`
+	ElementIDs []int    `json:"element_ids"`
+	Chars      int      `json:"chars"`
+	Tokens     int      `json:"tokens"`
+}
+
+// ChunksFormatter splits the document into chunks along heading sections and
+// outputs them as JSON Lines. Tables are never split.
+type ChunksFormatter struct {
+	MaxSize int    // maximum chunk size (default DefaultChunkSize)
+	Overlap int    // content repeated from the end of the previous chunk in the same section
+	Unit    string // chars or tokens (default chars)
+}
+
+func (f *ChunksFormatter) Format(resp *api.ParseResponse) (string, error) {
+	if f.Overlap < 0 || (f.MaxSize > 0 && f.Overlap >= f.MaxSize) {
+		return "", fmt.Errorf("chunk overlap must be between 0 and the chunk size")
+	}
+	if f.Unit != "" && f.Unit != ChunkUnitChars && f.Unit != ChunkUnitTokens {
+		return "", fmt.Errorf("unsupported chunk unit: %s", f.Unit)
+	}
+
+	var sb strings.Builder
+	for _, chunk := range f.Chunks(resp) {
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal chunk: %w", err)
+		}
+		sb.Write(data)
+		sb.WriteString("\n")
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// chunkPart is a piece of element content placed in a chunk
+type chunkPart struct {
+	elementID int
+	page      int
+	text      string
+	table     bool
+}
+
+// Chunks splits the response elements into chunks
+func (f *ChunksFormatter) Chunks(resp *api.ParseResponse) []Chunk {
+	maxSize := f.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultChunkSize
+	}
+
+	c := &chunker{maxSize: maxSize, overlap: f.Overlap, size: f.sizeFunc()}
+
+	for _, elem := range resp.Elements {
+		if elem.Category == api.CategoryHeader || elem.Category == api.CategoryFooter {
+			continue
+		}
+
+		text := strings.TrimSpace(elem.Content.Markdown)
+		if text == "" {
+			text = strings.TrimSpace(elem.Content.Text)
+		}
+
+		if level := headingLevel(elem.Category); level > 0 {
+			c.flush(false)
+			c.setHeading(level, headingText(elem))
+		}
+		if text == "" {
+			continue
+		}
+
+		part := chunkPart{elementID: elem.ID, page: elem.Page, text: text, table: elem.Category == api.CategoryTable}
+		if part.table || c.size(text) <= maxSize {
+			c.add(part)
+			continue
+		}
+
+		// Split long text elements on word boundaries
+		for _, piece := range splitText(text, maxSize, f.Overlap, c.size) {
+			c.add(chunkPart{elementID: elem.ID, page: elem.Page, text: piece})
+		}
+	}
+	c.flush(false)
+
+	return c.chunks
+}
+
+func (f *ChunksFormatter) sizeFunc() func(string) int {
+	if f.Unit == ChunkUnitTokens {
+		return EstimateTokens
+	}
+	return utf8.RuneCountInString
+}
+
+// EstimateTokens approximates the token count of text (about 4 characters per token)
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// chunker accumulates parts into chunks
+type chunker struct {
+	maxSize  int
+	overlap  int
+	size     func(string) int
+	headings []string
+	parts    []chunkPart
+	chunks   []Chunk
+}
+
+// partSeparator joins parts within a chunk
+const partSeparator = "\n\n"
+
+func (c *chunker) add(part chunkPart) {
+	if len(c.parts) > 0 && !c.fits(part) {
+		c.flush(true)
+		if !c.fits(part) {
+			// The overlap would push the chunk over the limit
+			c.parts = nil
+		}
+	}
+	c.parts = append(c.parts, part)
+}
+
+// fits reports whether part can be added to the current chunk
+func (c *chunker) fits(part chunkPart) bool {
+	parts := append(append([]chunkPart{}, c.parts...), part)
+	return c.size(c.text(parts)) <= c.maxSize
+}
+
+func (c *chunker) setHeading(level int, text string) {
+	if len(c.headings) >= level {
+		c.headings = c.headings[:level-1]
+	}
+	for len(c.headings) < level-1 {
+		c.headings = append(c.headings, "")
+	}
+	c.headings = append(c.headings, text)
+}
+
+// flush emits the current chunk. When keepOverlap is set, trailing non-table
+// parts that fit within the overlap are carried into the next chunk.
+func (c *chunker) flush(keepOverlap bool) {
+	if len(c.parts) == 0 {
+		return
+	}
+
+	chunk := Chunk{
+		ID:        len(c.chunks),
+		Text:      c.text(c.parts),
+		Headings:  append([]string{}, c.headings...),
+		PageStart: c.parts[0].page,
+		PageEnd:   c.parts[0].page,
+	}
+	for _, p := range c.parts {
+		if p.page < chunk.PageStart {
+			chunk.PageStart = p.page
+		}
+		if p.page > chunk.PageEnd {
+			chunk.PageEnd = p.page
+		}
+		if n := len(chunk.ElementIDs); n == 0 || chunk.ElementIDs[n-1] != p.elementID {
+			chunk.ElementIDs = append(chunk.ElementIDs, p.elementID)
+		}
+	}
+	chunk.Chars = utf8.RuneCountInString(chunk.Text)
+	chunk.Tokens = EstimateTokens(chunk.Text)
+	c.chunks = append(c.chunks, chunk)
+
+	var carry []chunkPart
+	if keepOverlap && c.overlap > 0 {
+		for i := len(c.parts) - 1; i > 0; i-- {
+			p := c.parts[i]
+			if p.table || c.size(c.text(append([]chunkPart{p}, carry...))) > c.overlap {
+				break
+			}
+			carry = append([]chunkPart{p}, carry...)
+		}
+	}
+	c.parts = carry
+}
+
+func (c *chunker) text(parts []chunkPart) string {
+	texts := make([]string, len(parts))
+	for i, p := range parts {
+		texts[i] = p.text
+	}
+	return strings.Join(texts, partSeparator)
+}
+
+// splitText splits text into pieces of at most maxSize, breaking on whitespace
+// where possible, with consecutive pieces sharing about overlap of content
+func splitText(text string, maxSize, overlap int, size func(string) int) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(text, unicode.IsSpace) {
+		words = append(words, splitWord(word, maxSize, size)...)
+	}
+
+	var pieces []string
+	var current []string
+
+	for _, word := range words {
+		candidate := strings.Join(append(current, word), " ")
+		if len(current) > 0 && size(candidate) > maxSize {
+			pieces = append(pieces, strings.Join(current, " "))
+
+			// Start the next piece with trailing words from this one
+			var carry []string
+			for i := len(current) - 1; i > 0; i-- {
+				next := append([]string{current[i]}, carry...)
+				if size(strings.Join(next, " ")) > overlap {
+					break
+				}
+				carry = next
+			}
+			if size(strings.Join(append(carry, word), " ")) > maxSize {
+				carry = nil
+			}
+			current = carry
+		}
+		current = append(current, word)
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, strings.Join(current, " "))
+	}
+
+	return pieces
+}
+
+// splitWord splits a word larger than maxSize into runs of runes, which keeps
+// text without spaces (such as Chinese or Japanese) within the limit
+func splitWord(word string, maxSize int, size func(string) int) []string {
+	if size(word) <= maxSize {
+		return []string{word}
+	}
+
+	var parts []string
+	runes := []rune(word)
+	start := 0
+	for i := range runes {
+		if i > start && size(string(runes[start:i+1])) > maxSize {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// headingLevel returns 1-6 for heading categories, or 0
+func headingLevel(category string) int {
+	switch category {
+	case api.CategoryHeading1:
+		return 1
+	case api.CategoryHeading2:
+		return 2
+	case api.CategoryHeading3:
+		return 3
+	case api.CategoryHeading4:
+		return 4
+	case api.CategoryHeading5:
+		return 5
+	case api.CategoryHeading6:
+		return 6
+	default:
+		return 0
+	}
+}
+
+// headingText returns the plain heading text without markdown markers
+func headingText(elem api.Element) string {
+	if text := strings.TrimSpace(elem.Content.Text); text != "" {
+		return text
+	}
+	return strings.TrimSpace(strings.TrimLeft(elem.Content.Markdown, "# "))
+}
@@ -0,0 +1,158 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/serithemage/updoc/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func element(id int, category string, page int, text string) api.Element {
+	md := text
+	switch category {
+	case api.CategoryHeading1:
+		md = "# " + text
+	case api.CategoryHeading2:
+		md = "## " + text
+	}
+	return api.Element{ID: id, Category: category, Page: page, Content: api.Content{Markdown: md, Text: text}}
+}
+
+func TestChunksFormatterSections(t *testing.T) {
+	resp := &api.ParseResponse{
+		Elements: []api.Element{
+			element(0, api.CategoryHeader, 1, "Company Confidential"),
+			element(1, api.CategoryHeading1, 1, "Guide"),
+			element(2, api.CategoryParagraph, 1, "Intro text."),
+			element(3, api.CategoryHeading2, 1, "Install"),
+			element(4, api.CategoryParagraph, 2, "Run the installer."),
+			element(5, api.CategoryHeading2, 3, "Usage"),
+			element(6, api.CategoryParagraph, 3, "Call the command."),
+		},
+	}
+
+	f := &ChunksFormatter{MaxSize: 1000}
+	chunks := f.Chunks(resp)
+
+	require.Len(t, chunks, 3)
+
+	assert.Equal(t, "# Guide\n\nIntro text.", chunks[0].Text)
+	assert.Equal(t, []string{"Guide"}, chunks[0].Headings)
+	assert.Equal(t, []int{1, 2}, chunks[0].ElementIDs)
+
+	assert.Equal(t, "## Install\n\nRun the installer.", chunks[1].Text)
+	assert.Equal(t, []string{"Guide", "Install"}, chunks[1].Headings)
+	assert.Equal(t, 1, chunks[1].PageStart)
+	assert.Equal(t, 2, chunks[1].PageEnd)
+
+	assert.Equal(t, []string{"Guide", "Usage"}, chunks[2].Headings)
+	assert.Equal(t, 2, chunks[2].ID)
+}
+
+func TestChunksFormatterSizeAndOverlap(t *testing.T) {
+	var elements []api.Element
+	for i := 0; i < 6; i++ {
+		elements = append(elements, element(i, api.CategoryParagraph, 1, strings.Repeat("a", 40)))
+	}
+
+	f := &ChunksFormatter{MaxSize: 100, Overlap: 50}
+	chunks := f.Chunks(&api.ParseResponse{Elements: elements})
+
+	require.Len(t, chunks, 5)
+	for _, c := range chunks {
+		assert.LessOrEqual(t, c.Chars, 100)
+	}
+	// Each chunk repeats the last paragraph of the previous one
+	assert.Equal(t, []int{0, 1}, chunks[0].ElementIDs)
+	assert.Equal(t, []int{1, 2}, chunks[1].ElementIDs)
+	assert.Equal(t, []int{4, 5}, chunks[4].ElementIDs)
+}
+
+func TestChunksFormatterNeverSplitsTables(t *testing.T) {
+	table := strings.Repeat("| a | b |\n", 50)
+	resp := &api.ParseResponse{
+		Elements: []api.Element{
+			element(0, api.CategoryParagraph, 1, "Before"),
+			element(1, api.CategoryTable, 1, table),
+			element(2, api.CategoryParagraph, 2, "After"),
+		},
+	}
+
+	f := &ChunksFormatter{MaxSize: 100, Overlap: 20}
+	chunks := f.Chunks(resp)
+
+	require.Len(t, chunks, 3)
+	assert.Equal(t, strings.TrimSpace(table), chunks[1].Text)
+	assert.Equal(t, []int{1}, chunks[1].ElementIDs)
+	// Tables are not repeated as overlap
+	assert.Equal(t, []int{2}, chunks[2].ElementIDs)
+}
+
+func TestChunksFormatterSplitsLongParagraph(t *testing.T) {
+	text := strings.TrimSpace(strings.Repeat("word ", 100))
+	resp := &api.ParseResponse{Elements: []api.Element{element(7, api.CategoryParagraph, 4, text)}}
+
+	f := &ChunksFormatter{MaxSize: 60, Overlap: 10}
+	chunks := f.Chunks(resp)
+
+	require.Greater(t, len(chunks), 1)
+	for _, c := range chunks {
+		assert.LessOrEqual(t, c.Chars, 60)
+		assert.Equal(t, []int{7}, c.ElementIDs)
+		assert.Equal(t, 4, c.PageStart)
+	}
+}
+
+func TestChunksFormatterTokens(t *testing.T) {
+	// Text without spaces is split on characters
+	text := strings.Repeat("가", 100)
+	resp := &api.ParseResponse{Elements: []api.Element{element(0, api.CategoryParagraph, 1, text)}}
+
+	f := &ChunksFormatter{MaxSize: 10, Unit: ChunkUnitTokens}
+	chunks := f.Chunks(resp)
+
+	require.Len(t, chunks, 3)
+	for _, c := range chunks {
+		assert.LessOrEqual(t, c.Tokens, 10)
+	}
+	assert.Equal(t, 100, utf8.RuneCountInString(chunks[0].Text+chunks[1].Text+chunks[2].Text))
+}
+
+func TestChunksFormatterJSONL(t *testing.T) {
+	resp := &api.ParseResponse{
+		Elements: []api.Element{
+			element(0, api.CategoryHeading1, 1, "A"),
+			element(1, api.CategoryParagraph, 1, "one"),
+			element(2, api.CategoryHeading1, 2, "B"),
+			element(3, api.CategoryParagraph, 2, "two"),
+		},
+	}
+
+	f, err := NewFormatter("chunks")
+	require.NoError(t, err)
+
+	result, err := f.Format(resp)
+	require.NoError(t, err)
+
+	lines := strings.Split(result, "\n")
+	require.Len(t, lines, 2)
+
+	var chunk Chunk
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &chunk))
+	assert.Equal(t, 1, chunk.ID)
+	assert.Equal(t, []string{"B"}, chunk.Headings)
+	assert.Equal(t, []int{2, 3}, chunk.ElementIDs)
+	assert.Equal(t, 2, chunk.PageStart)
+}
+
+func TestChunksFormatterInvalidOptions(t *testing.T) {
+	_, err := (&ChunksFormatter{MaxSize: 100, Overlap: 100}).Format(&api.ParseResponse{})
+	assert.Error(t, err)
+
+	_, err = (&ChunksFormatter{MaxSize: 100, Unit: "words"}).Format(&api.ParseResponse{})
+	assert.Error(t, err)
+}
@@ -24,23 +24,31 @@ func NewFormatter(format string) (Formatter, error) {
 		return &TextFormatter{}, nil
 	case "json":
 		return &JSONFormatter{}, nil
+	case "chunks":
+		return &ChunksFormatter{MaxSize: DefaultChunkSize, Overlap: DefaultChunkOverlap, Unit: ChunkUnitChars}, nil
 	default:
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
 // HTMLFormatter outputs HTML content
-type HTMLFormatter struct{}
+type HTMLFormatter struct {
+	ImagePaths map[int]string // extracted figure images by element ID (optional)
+}
 
 func (f *HTMLFormatter) Format(resp *api.ParseResponse) (string, error) {
-	return resp.Content.HTML, nil
+	html := func(c api.Content) string { return c.HTML }
+	return linkFigures(resp.Content.HTML, resp.Elements, f.ImagePaths, html, rewriteHTMLFigure), nil
 }
 
 // MarkdownFormatter outputs Markdown content
-type MarkdownFormatter struct{}
+type MarkdownFormatter struct {
+	ImagePaths map[int]string // extracted figure images by element ID (optional)
+}
 
 func (f *MarkdownFormatter) Format(resp *api.ParseResponse) (string, error) {
-	return resp.Content.Markdown, nil
+	markdown := func(c api.Content) string { return c.Markdown }
+	return linkFigures(resp.Content.Markdown, resp.Elements, f.ImagePaths, markdown, rewriteMarkdownFigure), nil
 }
 
 // TextFormatter outputs plain text content
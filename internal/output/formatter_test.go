@@ -17,6 +17,7 @@ func TestNewFormatter(t *testing.T) {
 		{"markdown", false},
 		{"text", false},
 		{"json", false},
+		{"chunks", false},
 		{"invalid", true},
 		{"", true},
 	}
@@ -0,0 +1,126 @@
+package output
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/serithemage/updoc/internal/api"
+)
+
+// ExtractImages decodes base64 element images into dir.
+// It returns the written file paths keyed by element ID.
+func ExtractImages(resp *api.ParseResponse, dir string) (map[int]string, error) {
+	images := make(map[int]string)
+
+	for _, elem := range resp.Elements {
+		if elem.Base64 == "" {
+			continue
+		}
+
+		data, err := base64.StdEncoding.DecodeString(elem.Base64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image for element %d: %w", elem.ID, err)
+		}
+
+		if len(images) == 0 {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create image directory: %w", err)
+			}
+		}
+
+		path := filepath.Join(dir, ImageFileName(elem, data))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write image: %w", err)
+		}
+		images[elem.ID] = path
+	}
+
+	return images, nil
+}
+
+// ImageFileName returns the file name for an element image, e.g. figure-3-12.png
+func ImageFileName(elem api.Element, data []byte) string {
+	ext := ".png"
+	if http.DetectContentType(data) == "image/jpeg" {
+		ext = ".jpg"
+	}
+	return fmt.Sprintf("%s-%d-%d%s", elem.Category, elem.Page, elem.ID, ext)
+}
+
+var (
+	markdownImageRe = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	htmlImgRe       = regexp.MustCompile(`<img\b[^>]*>`)
+	htmlSrcRe       = regexp.MustCompile(`\ssrc=("[^"]*"|'[^']*')`)
+	htmlFigureRe    = regexp.MustCompile(`<figure\b[^>]*>`)
+)
+
+// linkFigures rewrites each figure element in the document so it references
+// its extracted image. Element content is located in document order; figures
+// whose content cannot be found are left unchanged.
+func linkFigures(doc string, elements []api.Element, images map[int]string, content func(api.Content) string, rewrite func(string, string) string) string {
+	if len(images) == 0 {
+		return doc
+	}
+
+	var sb strings.Builder
+	pos := 0
+	for _, elem := range elements {
+		path, ok := images[elem.ID]
+		if !ok || elem.Category != api.CategoryFigure {
+			continue
+		}
+		original := content(elem.Content)
+		if original == "" {
+			continue
+		}
+		idx := strings.Index(doc[pos:], original)
+		if idx < 0 {
+			continue
+		}
+		sb.WriteString(doc[pos : pos+idx])
+		sb.WriteString(rewrite(original, filepath.ToSlash(path)))
+		pos += idx + len(original)
+	}
+	sb.WriteString(doc[pos:])
+
+	return sb.String()
+}
+
+// rewriteMarkdownFigure points the figure's image references at path,
+// adding one if the figure has none
+func rewriteMarkdownFigure(md, path string) string {
+	path = strings.ReplaceAll(path, " ", "%20")
+	if markdownImageRe.MatchString(md) {
+		return markdownImageRe.ReplaceAllStringFunc(md, func(img string) string {
+			alt := markdownImageRe.FindStringSubmatch(img)[1]
+			return "![" + alt + "](" + path + ")"
+		})
+	}
+	return "![figure](" + path + ")\n" + md
+}
+
+// rewriteHTMLFigure points the figure's <img> tags at path, adding one if the figure has none
+func rewriteHTMLFigure(doc, path string) string {
+	src := ` src="` + html.EscapeString(path) + `"`
+
+	if htmlImgRe.MatchString(doc) {
+		return htmlImgRe.ReplaceAllStringFunc(doc, func(tag string) string {
+			if loc := htmlSrcRe.FindStringIndex(tag); loc != nil {
+				return tag[:loc[0]] + src + tag[loc[1]:]
+			}
+			return strings.Replace(tag, "<img", "<img"+src, 1)
+		})
+	}
+
+	img := `<img` + src + ` alt="figure"/>`
+	if loc := htmlFigureRe.FindStringIndex(doc); loc != nil {
+		return doc[:loc[1]] + img + doc[loc[1]:]
+	}
+	return img + doc
+}
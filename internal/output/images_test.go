@@ -0,0 +1,107 @@
+package output
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/serithemage/updoc/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 1x1 transparent PNG
+const testPNG = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg=="
+
+func figureResponse() *api.ParseResponse {
+	return &api.ParseResponse{
+		Content: api.Content{
+			HTML:     "<h1 id='0'>Title</h1><figure id='1'><img src='/image/placeholder' alt='chart'/></figure><p id='2'>Body</p>",
+			Markdown: "# Title\n\n![chart](/image/placeholder)\n\nBody",
+		},
+		Elements: []api.Element{
+			{
+				ID: 0, Category: api.CategoryHeading1, Page: 1,
+				Content: api.Content{HTML: "<h1 id='0'>Title</h1>", Markdown: "# Title"},
+			},
+			{
+				ID: 1, Category: api.CategoryFigure, Page: 2, Base64: testPNG,
+				Content: api.Content{
+					HTML:     "<figure id='1'><img src='/image/placeholder' alt='chart'/></figure>",
+					Markdown: "![chart](/image/placeholder)",
+				},
+			},
+			{
+				ID: 2, Category: api.CategoryParagraph, Page: 2,
+				Content: api.Content{HTML: "<p id='2'>Body</p>", Markdown: "Body"},
+			},
+		},
+	}
+}
+
+func TestExtractImages(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "images")
+
+	images, err := ExtractImages(figureResponse(), dir)
+	require.NoError(t, err)
+
+	require.Len(t, images, 1)
+	assert.Equal(t, filepath.Join(dir, "figure-2-1.png"), images[1])
+
+	data, err := os.ReadFile(images[1])
+	require.NoError(t, err)
+	expected, _ := base64.StdEncoding.DecodeString(testPNG)
+	assert.Equal(t, expected, data)
+}
+
+func TestExtractImagesNone(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "images")
+
+	images, err := ExtractImages(&api.ParseResponse{}, dir)
+	require.NoError(t, err)
+	assert.Empty(t, images)
+
+	// No directory is created when there is nothing to write
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExtractImagesInvalidBase64(t *testing.T) {
+	resp := &api.ParseResponse{
+		Elements: []api.Element{{ID: 1, Category: api.CategoryFigure, Base64: "not base64!"}},
+	}
+
+	_, err := ExtractImages(resp, t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestMarkdownFormatterLinksFigures(t *testing.T) {
+	f := &MarkdownFormatter{ImagePaths: map[int]string{1: "images/figure-2-1.png"}}
+
+	result, err := f.Format(figureResponse())
+	require.NoError(t, err)
+
+	assert.Equal(t, "# Title\n\n![chart](images/figure-2-1.png)\n\nBody", result)
+}
+
+func TestHTMLFormatterLinksFigures(t *testing.T) {
+	f := &HTMLFormatter{ImagePaths: map[int]string{1: "images/figure-2-1.png"}}
+
+	result, err := f.Format(figureResponse())
+	require.NoError(t, err)
+
+	assert.Equal(t, `<h1 id='0'>Title</h1><figure id='1'><img src="images/figure-2-1.png" alt='chart'/></figure><p id='2'>Body</p>`, result)
+}
+
+func TestRewriteMarkdownFigure(t *testing.T) {
+	assert.Equal(t, "![a](img/x%20y.png)", rewriteMarkdownFigure("![a](orig.png)", "img/x y.png"))
+	assert.Equal(t, "![figure](x.png)\nCaption", rewriteMarkdownFigure("Caption", "x.png"))
+}
+
+func TestRewriteHTMLFigure(t *testing.T) {
+	assert.Equal(t, `<figure id='1'><img src="x.png" alt='a'/></figure>`,
+		rewriteHTMLFigure(`<figure id='1'><img alt='a'/></figure>`, "x.png"))
+	assert.Equal(t, `<figure id='1'><img src="x.png" alt="figure"/>Caption</figure>`,
+		rewriteHTMLFigure(`<figure id='1'>Caption</figure>`, "x.png"))
+}
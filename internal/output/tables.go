@@ -0,0 +1,283 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/serithemage/updoc/internal/api"
+)
+
+// Table export formats
+const (
+	TableFormatCSV  = "csv"
+	TableFormatTSV  = "tsv"
+	TableFormatXLSX = "xlsx"
+)
+
+// Span limits, as in the HTML standard, so a malformed table cannot blow up the grid
+const (
+	maxColspan = 1000
+	maxRowspan = 65534
+)
+
+// ValidTableFormats lists the supported table export formats
+var ValidTableFormats = []string{TableFormatCSV, TableFormatTSV, TableFormatXLSX}
+
+// Table is a table element with merged cells expanded into a rectangular grid
+type Table struct {
+	ElementID int
+	Page      int
+	Rows      [][]string
+}
+
+// Name returns the base name used for the table's file or sheet, e.g. table-3-12
+func (t Table) Name() string {
+	return fmt.Sprintf("table-%d-%d", t.Page, t.ElementID)
+}
+
+// ExtractTables parses the HTML of every table element in the response
+func ExtractTables(resp *api.ParseResponse) ([]Table, error) {
+	var tables []Table
+
+	for _, elem := range resp.Elements {
+		if elem.Category != api.CategoryTable || elem.Content.HTML == "" {
+			continue
+		}
+
+		rows, err := ParseHTMLTable(elem.Content.HTML)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse table %d: %w", elem.ID, err)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		tables = append(tables, Table{ElementID: elem.ID, Page: elem.Page, Rows: rows})
+	}
+
+	return tables, nil
+}
+
+// htmlCell is a cell as written in the HTML, before spans are expanded
+type htmlCell struct {
+	text    string
+	rowspan int
+	colspan int
+}
+
+// ParseHTMLTable parses an HTML table into rows of cell text.
+// Cells spanning several rows or columns are repeated in every position they cover.
+func ParseHTMLTable(html string) ([][]string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(html))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var rows [][]htmlCell
+	var cell *htmlCell
+	var text strings.Builder
+	depth := 0 // nesting level of <table> elements
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "table":
+				depth++
+			case depth > 1:
+				// Nested tables are flattened into the enclosing cell's text
+			case name == "tr":
+				rows = append(rows, nil)
+			case name == "td" || name == "th":
+				if len(rows) == 0 {
+					rows = append(rows, nil)
+				}
+				cell = &htmlCell{rowspan: spanAttr(t, "rowspan", maxRowspan), colspan: spanAttr(t, "colspan", maxColspan)}
+				text.Reset()
+			case name == "br" && cell != nil:
+				text.WriteString("\n")
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "table":
+				depth--
+			case depth > 1:
+			case (name == "td" || name == "th") && cell != nil:
+				cell.text = normalizeCellText(text.String())
+				rows[len(rows)-1] = append(rows[len(rows)-1], *cell)
+				cell = nil
+			}
+		case xml.CharData:
+			if cell != nil {
+				// Source line breaks are whitespace; only <br> breaks a line
+				text.WriteString(strings.NewReplacer("\r", " ", "\n", " ").Replace(string(t)))
+			}
+		}
+	}
+
+	return expandSpans(rows), nil
+}
+
+// expandSpans lays cells out on a grid, filling every position a span covers
+func expandSpans(rows [][]htmlCell) [][]string {
+	var grid [][]string
+	var filled [][]bool
+
+	ensure := func(r, c int) {
+		for len(grid) <= r {
+			grid = append(grid, nil)
+			filled = append(filled, nil)
+		}
+		for len(grid[r]) <= c {
+			grid[r] = append(grid[r], "")
+			filled[r] = append(filled[r], false)
+		}
+	}
+
+	width := 0
+	for r, row := range rows {
+		c := 0
+		for _, cell := range row {
+			ensure(r, c)
+			for filled[r][c] {
+				c++
+				ensure(r, c)
+			}
+			// Rows past the end of the table are dropped below, so they are never filled
+			rowspan := min(cell.rowspan, len(rows)-r)
+			for dr := 0; dr < rowspan; dr++ {
+				for dc := 0; dc < cell.colspan; dc++ {
+					ensure(r+dr, c+dc)
+					grid[r+dr][c+dc] = cell.text
+					filled[r+dr][c+dc] = true
+				}
+			}
+			c += cell.colspan
+			if c > width {
+				width = c
+			}
+		}
+	}
+
+	// Pad rows to the same width and drop rows that only exist because of a rowspan past the end
+	var result [][]string
+	for r, row := range grid {
+		if r >= len(rows) {
+			break
+		}
+		for len(row) < width {
+			row = append(row, "")
+		}
+		result = append(result, row)
+	}
+	return result
+}
+
+// spanAttr returns the rowspan or colspan attribute of el, at most limit
+func spanAttr(el xml.StartElement, name string, limit int) int {
+	for _, attr := range el.Attr {
+		if strings.EqualFold(attr.Name.Local, name) {
+			if n, err := strconv.Atoi(strings.TrimSpace(attr.Value)); err == nil && n > 0 {
+				return min(n, limit)
+			}
+		}
+	}
+	return 1
+}
+
+// normalizeCellText collapses whitespace within each line of a cell
+func normalizeCellText(s string) string {
+	lines := strings.Split(s, "\n")
+	var kept []string
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// WriteTableCSV writes rows as CSV, or TSV when comma is '\t'
+func WriteTableCSV(w io.Writer, rows [][]string, comma rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write table: %w", err)
+	}
+	return nil
+}
+
+// WriteTables writes tables to dir in the given format. CSV and TSV produce one
+// file per table; XLSX produces a single workbook named name.xlsx with a sheet
+// per table. It returns the paths written.
+func WriteTables(tables []Table, dir, name, format string) ([]string, error) {
+	comma, ext := ',', ".csv"
+	switch format {
+	case TableFormatCSV, TableFormatXLSX:
+	case TableFormatTSV:
+		comma, ext = '\t', ".tsv"
+	default:
+		return nil, fmt.Errorf("unsupported table format: %s", format)
+	}
+
+	if len(tables) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create tables directory: %w", err)
+	}
+
+	if format == TableFormatXLSX {
+		sheets := make([]Sheet, len(tables))
+		for i, t := range tables {
+			sheets[i] = Sheet{Name: t.Name(), Rows: t.Rows}
+		}
+		path := filepath.Join(dir, name+".xlsx")
+		if err := writeFile(path, func(w io.Writer) error { return WriteXLSX(w, sheets) }); err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	}
+
+	var paths []string
+	for _, t := range tables {
+		path := filepath.Join(dir, t.Name()+ext)
+		if err := writeFile(path, func(w io.Writer) error { return WriteTableCSV(w, t.Rows, comma) }); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// writeFile creates path and writes its content with write
+func writeFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if err := write(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
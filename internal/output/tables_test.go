@@ -0,0 +1,197 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/serithemage/updoc/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHTMLTable(t *testing.T) {
+	html := `<table id='3'><thead><tr><th>Name</th><th>Q1</th><th>Q2</th></tr></thead>` +
+		`<tbody><tr><td>A &amp; B</td><td>1</td><td>2</td></tr></tbody></table>`
+
+	rows, err := ParseHTMLTable(html)
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		{"Name", "Q1", "Q2"},
+		{"A & B", "1", "2"},
+	}, rows)
+}
+
+func TestParseHTMLTableSpans(t *testing.T) {
+	html := `<table><tr><td rowspan='2'>Region</td><td colspan="2">Sales</td></tr>` +
+		`<tr><td>2023</td><td>2024</td></tr>` +
+		`<tr><td>East</td><td rowspan=2 colspan=2>n/a</td></tr>` +
+		`<tr><td>West</td></tr></table>`
+
+	rows, err := ParseHTMLTable(html)
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		{"Region", "Sales", "Sales"},
+		{"Region", "2023", "2024"},
+		{"East", "n/a", "n/a"},
+		{"West", "n/a", "n/a"},
+	}, rows)
+}
+
+func TestParseHTMLTableRaggedRowsAndLineBreaks(t *testing.T) {
+	html := "<table><tr><td>a<br>b</td><td>  spaced \n  out </td></tr><tr><td>only</td></tr></table>"
+
+	rows, err := ParseHTMLTable(html)
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		{"a\nb", "spaced out"},
+		{"only", ""},
+	}, rows)
+}
+
+func TestParseHTMLTableRowspanPastEnd(t *testing.T) {
+	rows, err := ParseHTMLTable("<table><tr><td rowspan='3'>x</td><td>y</td></tr></table>")
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{{"x", "y"}}, rows)
+}
+
+func TestParseHTMLTableOversizedSpans(t *testing.T) {
+	rows, err := ParseHTMLTable("<table><tr><td rowspan='999999999' colspan='999999999'>x</td><td>y</td></tr>" +
+		"<tr><td>z</td></tr></table>")
+	require.NoError(t, err)
+
+	// colspan is capped at 1000 columns and rowspan at the rows of the table
+	require.Len(t, rows, 2)
+	assert.Len(t, rows[0], 1001)
+	assert.Equal(t, "y", rows[0][1000])
+	assert.Equal(t, "x", rows[1][999])
+	assert.Equal(t, "z", rows[1][1000])
+}
+
+func tableResponse() *api.ParseResponse {
+	return &api.ParseResponse{
+		Elements: []api.Element{
+			{ID: 0, Category: api.CategoryParagraph, Page: 1, Content: api.Content{HTML: "<p id='0'>Intro</p>"}},
+			{ID: 1, Category: api.CategoryTable, Page: 1, Content: api.Content{HTML: "<table id='1'><tr><td>a</td><td>b</td></tr></table>"}},
+			{ID: 4, Category: api.CategoryTable, Page: 3, Content: api.Content{HTML: "<table id='4'><tr><td>c,d</td></tr></table>"}},
+			{ID: 5, Category: api.CategoryTable, Page: 3, Content: api.Content{Text: "no html"}},
+		},
+	}
+}
+
+func TestExtractTables(t *testing.T) {
+	tables, err := ExtractTables(tableResponse())
+	require.NoError(t, err)
+
+	require.Len(t, tables, 2)
+	assert.Equal(t, "table-1-1", tables[0].Name())
+	assert.Equal(t, [][]string{{"a", "b"}}, tables[0].Rows)
+	assert.Equal(t, "table-3-4", tables[1].Name())
+}
+
+func TestWriteTablesCSV(t *testing.T) {
+	tables, err := ExtractTables(tableResponse())
+	require.NoError(t, err)
+
+	dir := filepath.Join(t.TempDir(), "tables")
+	paths, err := WriteTables(tables, dir, "doc", TableFormatCSV)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{filepath.Join(dir, "table-1-1.csv"), filepath.Join(dir, "table-3-4.csv")}, paths)
+
+	data, err := os.ReadFile(paths[1])
+	require.NoError(t, err)
+	assert.Equal(t, "\"c,d\"\n", string(data))
+}
+
+func TestWriteTablesTSV(t *testing.T) {
+	tables, err := ExtractTables(tableResponse())
+	require.NoError(t, err)
+
+	paths, err := WriteTables(tables, t.TempDir(), "doc", TableFormatTSV)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	assert.Equal(t, "a\tb\n", string(data))
+	assert.Equal(t, ".tsv", filepath.Ext(paths[0]))
+}
+
+func TestWriteTablesXLSX(t *testing.T) {
+	tables, err := ExtractTables(tableResponse())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	paths, err := WriteTables(tables, dir, "doc", TableFormatXLSX)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "doc.xlsx")}, paths)
+
+	files := readZip(t, paths[0])
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="table-1-1" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="table-3-4" sheetId="2" r:id="rId2"/>`)
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"], `<c r="B1" t="inlineStr"><is><t xml:space="preserve">b</t></is></c>`)
+	assert.Contains(t, files["[Content_Types].xml"], `/xl/worksheets/sheet2.xml`)
+}
+
+func TestWriteTablesNone(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tables")
+
+	paths, err := WriteTables(nil, dir, "doc", TableFormatCSV)
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWriteTablesUnsupportedFormat(t *testing.T) {
+	_, err := WriteTables([]Table{{Rows: [][]string{{"a"}}}}, t.TempDir(), "doc", "ods")
+	assert.Error(t, err)
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "AZ", columnName(51))
+	assert.Equal(t, "BA", columnName(52))
+}
+
+func TestUniqueSheetNames(t *testing.T) {
+	names := uniqueSheetNames([]Sheet{
+		{Name: "a/b"},
+		{Name: "A-B"},
+		{Name: ""},
+		{Name: "this sheet name is far too long for excel"},
+	})
+
+	assert.Equal(t, []string{"a-b", "A-B (2)", "Sheet3", "this sheet name is far too long"}, names)
+}
+
+func readZip(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		_ = rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
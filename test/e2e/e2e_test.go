@@ -10,11 +10,17 @@ package e2e
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -150,6 +156,214 @@ func TestConfigSetGet(t *testing.T) {
 	assert.Contains(t, stdout, "html")
 }
 
+func TestConfigProfiles(t *testing.T) {
+	t.Setenv("UPDOC_PROFILE", "")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	config := func(args ...string) (string, error) {
+		stdout, _, err := runUpdoc(t, append([]string{"--config", configPath}, args...)...)
+		return stdout, err
+	}
+
+	_, err := config("config", "set", "default-format", "html")
+	require.NoError(t, err)
+	_, err = config("config", "profile", "create", "staging", "--endpoint", "https://staging.example.com/v1")
+	require.NoError(t, err)
+	_, err = config("--profile", "staging", "config", "set", "default-mode", "enhanced")
+	require.NoError(t, err)
+
+	// Profiles do not share settings
+	stdout, err := config("--profile", "staging", "config", "get", "default-format")
+	require.NoError(t, err)
+	assert.Equal(t, "markdown\n", stdout)
+	stdout, err = config("config", "get", "default-mode")
+	require.NoError(t, err)
+	assert.Equal(t, "standard\n", stdout)
+
+	_, err = config("config", "profile", "use", "staging")
+	require.NoError(t, err)
+	stdout, err = config("config", "get", "endpoint")
+	require.NoError(t, err)
+	assert.Equal(t, "https://staging.example.com/v1\n", stdout)
+
+	stdout, err = config("config", "profile", "list")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "  default")
+	assert.Contains(t, stdout, "* staging")
+
+	_, err = config("--profile", "missing", "config", "list")
+	assert.Equal(t, 2, exitCode(err))
+
+	// Resetting a profile keeps the other profiles
+	_, err = config("config", "reset", "--force")
+	require.NoError(t, err)
+	stdout, err = config("config", "get", "default-mode")
+	require.NoError(t, err)
+	assert.Equal(t, "standard\n", stdout)
+
+	_, err = config("config", "profile", "delete", "staging", "--force")
+	require.NoError(t, err)
+	stdout, err = config("config", "get", "default-format")
+	require.NoError(t, err)
+	assert.Equal(t, "html\n", stdout)
+
+	_, err = config("config", "reset", "--all", "--force")
+	require.NoError(t, err)
+	_, err = os.Stat(configPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestConfigProjectFile(t *testing.T) {
+	t.Setenv("UPDOC_PROFILE", "")
+	t.Setenv("UPSTAGE_API_KEY", "")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	project := t.TempDir()
+	workDir := filepath.Join(project, "docs", "reports")
+	require.NoError(t, os.MkdirAll(workDir, 0755))
+
+	runIn := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, append([]string{"--config", configPath}, args...)...)
+		cmd.Dir = workDir
+		out, err := cmd.Output()
+		return string(out), err
+	}
+
+	_, err := runIn("config", "set", "default-format", "html")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".updoc.yaml"), []byte("default_mode: enhanced\n"), 0644))
+
+	stdout, err := runIn("config", "get", "default-mode")
+	require.NoError(t, err)
+	assert.Equal(t, "enhanced\n", stdout)
+
+	stdout, err = runIn("config", "list", "--show-origin")
+	require.NoError(t, err)
+	assert.Regexp(t, `default-mode:\s+enhanced\s+project \(.*\.updoc\.yaml\)`, stdout)
+	assert.Regexp(t, `default-format:\s+html\s+user config`, stdout)
+
+	// Secrets are refused
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".updoc.yaml"), []byte("api_key: secret\n"), 0644))
+	_, err = runIn("config", "list")
+	assert.Equal(t, 2, exitCode(err))
+
+	// Project presets must not write outside the project
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".updoc.yaml"), []byte("presets:\n  out:\n    output_dir: /tmp/out\n"), 0644))
+	_, err = runIn("config", "preset", "list")
+	assert.Equal(t, 2, exitCode(err))
+
+	// A project preset shadowing a user preset is reported
+	f, err := os.OpenFile(configPath, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString("presets:\n  scanned:\n    mode: enhanced\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".updoc.yaml"), []byte("presets:\n  scanned:\n    ocr: force\n"), 0644))
+	cmd := exec.Command(binaryPath, "--config", configPath, "config", "preset", "show", "scanned")
+	cmd.Dir = workDir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "force")
+	assert.Contains(t, stderr.String(), "Warning: preset scanned")
+}
+
+func TestAPIKeyCommandFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	t.Setenv("UPSTAGE_API_KEY", "")
+	t.Setenv("UPDOC_PROFILE", "")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	_, _, err := runUpdoc(t, "--config", configPath, "config", "set", "api-key-command", "exit 1")
+	require.NoError(t, err)
+
+	_, stderr, err := runUpdoc(t, "--config", configPath, "parse", filepath.Join(testdataDir, "dummy.pdf"))
+	assert.Equal(t, 5, exitCode(err))
+	assert.Contains(t, stderr, "api_key_command failed")
+}
+
+func TestConfigEncryptedAPIKey(t *testing.T) {
+	t.Setenv("UPSTAGE_API_KEY", "")
+	t.Setenv("UPDOC_PROFILE", "")
+	t.Setenv("UPDOC_PASSPHRASE", "correct horse")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	_, _, err := runUpdoc(t, "--config", configPath, "config", "set", "api-key", "up_encrypted_key", "--encrypt")
+	require.NoError(t, err)
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "up_encrypted_key")
+
+	stdout, _, err := runUpdoc(t, "--config", configPath, "config", "list")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "(encrypted)")
+
+	// Without a passphrase or terminal, commands that need the key fail with an auth error
+	t.Setenv("UPDOC_PASSPHRASE", "")
+	_, stderr, err := runUpdoc(t, "--config", configPath, "parse", filepath.Join(testdataDir, "dummy.pdf"))
+	assert.Equal(t, 5, exitCode(err))
+	assert.Contains(t, stderr, "UPDOC_PASSPHRASE")
+
+	_, _, err = runUpdoc(t, "--config", configPath, "config", "set", "endpoint", "x", "--encrypt")
+	assert.Equal(t, 2, exitCode(err))
+
+	// Keys are only decrypted when a command needs them
+	t.Setenv("UPDOC_PASSPHRASE", "wrong")
+	_, _, err = runUpdoc(t, "--config", configPath, "config", "list")
+	require.NoError(t, err)
+	_, stderr, err = runUpdoc(t, "--config", configPath, "parse", filepath.Join(testdataDir, "dummy.pdf"))
+	assert.Equal(t, 5, exitCode(err))
+	assert.Contains(t, stderr, "failed to decrypt")
+
+	// A profile encrypted with another passphrase does not affect the default profile
+	_, _, err = runUpdoc(t, "--config", configPath, "config", "profile", "create", "staging")
+	require.NoError(t, err)
+	t.Setenv("UPDOC_PASSPHRASE", "staging pass")
+	_, _, err = runUpdoc(t, "--config", configPath, "--profile", "staging", "config", "set", "api-key", "up_staging_key", "--encrypt")
+	require.NoError(t, err)
+	stdout, _, err = runUpdoc(t, "--config", configPath, "--profile", "staging", "config", "get", "api-key")
+	require.NoError(t, err)
+	assert.Equal(t, "(encrypted)\n", stdout)
+}
+
+func TestConfigPresets(t *testing.T) {
+	t.Setenv("UPDOC_PROFILE", "")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := "presets:\n  scanned:\n    mode: enhanced\n    ocr: force\n    merge_tables: true\n    coordinates: false\n  bad:\n    mode: turbo\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(data), 0600))
+
+	stdout, _, err := runUpdoc(t, "--config", configPath, "config", "preset", "list")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "scanned")
+	assert.Contains(t, stdout, "--mode enhanced --ocr force --merge-tables --coordinates=false")
+
+	stdout, _, err = runUpdoc(t, "--config", configPath, "config", "preset", "show", "scanned")
+	require.NoError(t, err)
+	assert.Regexp(t, `ocr:\s+force`, stdout)
+
+	_, _, err = runUpdoc(t, "--config", configPath, "config", "preset", "show", "missing")
+	assert.Equal(t, 2, exitCode(err))
+
+	// Unknown and invalid presets fail before anything is uploaded
+	file := filepath.Join(testdataDir, "dummy.pdf")
+	_, _, err = runUpdoc(t, "--config", configPath, "--api-key", "dummy", "parse", file, "--preset", "missing")
+	assert.Equal(t, 2, exitCode(err))
+	_, stderr, err := runUpdoc(t, "--config", configPath, "--api-key", "dummy", "parse", file, "--preset", "bad")
+	assert.Equal(t, 2, exitCode(err))
+	assert.Contains(t, stderr, "invalid mode")
+
+	// Named profiles use the presets of the default profile
+	_, _, err = runUpdoc(t, "--config", configPath, "config", "profile", "create", "staging")
+	require.NoError(t, err)
+	stdout, _, err = runUpdoc(t, "--config", configPath, "--profile", "staging", "config", "preset", "show", "scanned")
+	require.NoError(t, err)
+	assert.Regexp(t, `mode:\s+enhanced`, stdout)
+	// The preset is found, so parse gets as far as the missing file
+	_, _, err = runUpdoc(t, "--config", configPath, "--profile", "staging", "--api-key", "dummy", "parse", "/nonexistent/file.pdf", "--preset", "scanned")
+	assert.Equal(t, 4, exitCode(err))
+}
+
 func TestModels(t *testing.T) {
 	stdout, _, err := runUpdoc(t, "models")
 	require.NoError(t, err)
@@ -194,6 +408,61 @@ func TestParseUnsupportedFormat(t *testing.T) {
 	assert.Contains(t, combined, "unsupported file format")
 }
 
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		return -1
+	}
+	return 0
+}
+
+func TestExitCodes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "updoc-e2e-*")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	unsupported := filepath.Join(tmpDir, "test.xyz")
+	require.NoError(t, os.WriteFile(unsupported, []byte("x"), 0644))
+
+	originalKey := os.Getenv("UPSTAGE_API_KEY")
+	_ = os.Unsetenv("UPSTAGE_API_KEY")
+	defer func() {
+		if originalKey != "" {
+			_ = os.Setenv("UPSTAGE_API_KEY", originalKey)
+		}
+	}()
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"unknown flag", []string{"parse", "--no-such-flag"}, 2},
+		{"missing argument", []string{"parse"}, 2},
+		{"unknown command", []string{"no-such-command"}, 2},
+		{"invalid config value", []string{"config", "set", "default-format", "pdf"}, 2},
+		{"unsupported file", []string{"--api-key", "dummy", "parse", unsupported}, 2},
+		{"file not found", []string{"--api-key", "dummy", "parse", "/nonexistent/file.pdf"}, 4},
+		{"missing API key", []string{"parse", filepath.Join(testdataDir, "dummy.pdf")}, 5},
+		{"wait without async", []string{"--api-key", "dummy", "parse", filepath.Join(testdataDir, "dummy.pdf"), "--wait"}, 2},
+		{"negative rate limit", []string{"--api-key", "dummy", "--rate-limit", "-1", "parse", filepath.Join(testdataDir, "dummy.pdf")}, 2},
+		{"negative pages per minute", []string{"--api-key", "dummy", "--pages-per-minute", "-5", "parse", filepath.Join(testdataDir, "dummy.pdf")}, 2},
+		{"zero retry max wait", []string{"--api-key", "dummy", "--retry-max-wait", "0", "parse", filepath.Join(testdataDir, "dummy.pdf")}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"--config", configPath}, tt.args...)
+			_, stderr, err := runUpdoc(t, args...)
+			assert.Equal(t, tt.code, exitCode(err), "stderr: %s", stderr)
+		})
+	}
+}
+
 // ============================================================
 // API Tests (requires UPSTAGE_API_KEY)
 // ============================================================
@@ -270,6 +539,28 @@ func TestParseAsync(t *testing.T) {
 	assert.Contains(t, stdout, "Request ID")
 }
 
+func TestParseAsyncWait(t *testing.T) {
+	apiKey := requireAPIKey(t)
+	pdfFile := filepath.Join(testdataDir, "dummy.pdf")
+	outputFile := filepath.Join(t.TempDir(), "output.md")
+
+	stdout, _, err := runUpdoc(t, "--api-key", apiKey, "parse", pdfFile, "--async", "--wait", "--no-cache", "-o", outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Request ID")
+	assert.FileExists(t, outputFile)
+}
+
+func TestParseAsyncBatch(t *testing.T) {
+	apiKey := requireAPIKey(t)
+	outDir := t.TempDir()
+
+	stdout, _, err := runUpdoc(t, "--api-key", apiKey, "parse", testdataDir, "--async", "--no-cache", "-d", outDir)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Summary")
+	assert.FileExists(t, filepath.Join(outDir, "dummy.md"))
+	assert.FileExists(t, filepath.Join(outDir, "updoc-manifest.json"))
+}
+
 func TestVerboseMode(t *testing.T) {
 	apiKey := requireAPIKey(t)
 	pdfFile := filepath.Join(testdataDir, "dummy.pdf")
@@ -287,3 +578,248 @@ func TestQuietMode(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotContains(t, stdout, "Parsing")
 }
+
+func TestTablesFromSavedResult(t *testing.T) {
+	dir := t.TempDir()
+	resultFile := filepath.Join(dir, "result.json")
+	result := `{"elements":[{"id":3,"page":2,"category":"table",` +
+		`"content":{"html":"<table id='3'><tr><td rowspan='2'>A</td><td>B</td></tr><tr><td>C</td></tr></table>"}}]}`
+	require.NoError(t, os.WriteFile(resultFile, []byte(result), 0644))
+
+	outDir := filepath.Join(dir, "tables")
+	stdout, _, err := runUpdoc(t, "tables", resultFile, "-d", outDir)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Exported 1 tables")
+
+	data, err := os.ReadFile(filepath.Join(outDir, "table-2-3.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "A,B\nA,C\n", string(data))
+
+	_, _, err = runUpdoc(t, "tables", resultFile, "--format", "ods")
+	assert.Equal(t, 2, exitCode(err))
+}
+
+func TestConvertSavedResult(t *testing.T) {
+	dir := t.TempDir()
+	result := `{"content":{"markdown":"# Title\n\nBody","text":"Title\nBody"},` +
+		`"elements":[{"id":0,"page":1,"category":"heading1","content":{"markdown":"# Title","text":"Title"}},` +
+		`{"id":1,"page":1,"category":"paragraph","content":{"markdown":"Body","text":"Body"}}]}`
+	for _, name := range []string{"a.json", "b.json"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(result), 0644))
+	}
+
+	stdout, _, err := runUpdoc(t, "convert", filepath.Join(dir, "a.json"), "-f", "markdown")
+	require.NoError(t, err)
+	assert.Equal(t, "# Title\n\nBody\n", stdout)
+
+	stdout, _, err = runUpdoc(t, "convert", filepath.Join(dir, "a.json"), "-e", "-f", "text")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "[1] paragraph (page 1)")
+
+	outDir := filepath.Join(dir, "out")
+	_, _, err = runUpdoc(t, "convert", dir, "-f", "text", "-d", outDir)
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(outDir, "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "Title\nBody", string(data))
+
+	_, _, err = runUpdoc(t, "convert", dir, "-f", "text")
+	assert.Equal(t, 2, exitCode(err))
+}
+
+func TestConvertBatchOutput(t *testing.T) {
+	result := `{"content":{"markdown":"# Title","text":"Title"},` +
+		`"elements":[{"id":0,"page":1,"category":"heading1","content":{"markdown":"# Title","text":"Title"}}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(result))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	results := filepath.Join(dir, "results")
+	_, _, err := runUpdoc(t, "--api-key", "test-key", "--endpoint", server.URL, "parse", testdataDir,
+		"--json", "--no-cache", "-d", results)
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(results, "updoc-manifest.json"))
+
+	// The manifest next to the results is not converted
+	text := filepath.Join(dir, "text")
+	stdout, _, err := runUpdoc(t, "convert", results, "-f", "text", "-d", text)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Converting 2 files")
+	data, err := os.ReadFile(filepath.Join(text, "test.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "Title", string(data))
+	assert.NoFileExists(t, filepath.Join(text, "updoc-manifest.txt"))
+}
+
+func TestParseAsyncWaitTimeout(t *testing.T) {
+	t.Setenv("UPDOC_JOBS_DIR", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			// A slow upload does not count against --timeout
+			time.Sleep(1500 * time.Millisecond)
+			_, _ = w.Write([]byte(`{"request_id":"req-slow"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"request_id":"req-slow","status":"processing","progress":10}`))
+	}))
+	defer server.Close()
+
+	_, stderr, err := runUpdoc(t, "--api-key", "test-key", "--endpoint", server.URL, "parse",
+		filepath.Join(testdataDir, "dummy.pdf"), "--async", "--wait", "--timeout", "1", "--no-cache")
+	assert.Equal(t, 7, exitCode(err))
+	assert.Contains(t, stderr, "request req-slow is still running")
+}
+
+func TestCacheCommands(t *testing.T) {
+	t.Setenv("UPDOC_CACHE_DIR", filepath.Join(t.TempDir(), "cache"))
+
+	stdout, _, err := runUpdoc(t, "cache", "stats")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Entries:   0")
+
+	stdout, _, err = runUpdoc(t, "cache", "prune", "--older-than", "7d")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Removed 0")
+
+	_, _, err = runUpdoc(t, "cache", "prune", "--older-than", "soon")
+	assert.Equal(t, 2, exitCode(err))
+
+	stdout, _, err = runUpdoc(t, "cache", "clear")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Removed 0")
+}
+
+func TestParseRetryFailedErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, _, err := runUpdoc(t, "--api-key", "test-key", "parse", "--retry-failed", filepath.Join(dir, "missing.json"))
+	assert.Equal(t, 4, exitCode(err))
+
+	_, _, err = runUpdoc(t, "--api-key", "test-key", "parse", dir, "--retry-failed", filepath.Join(dir, "missing.json"))
+	assert.Equal(t, 2, exitCode(err))
+
+	// A manifest without failures has nothing to retry
+	manifest := filepath.Join(dir, "updoc-manifest.json")
+	require.NoError(t, os.WriteFile(manifest, []byte(`{"version":1,"files":[{"source":"/docs/a.pdf","status":"completed"}]}`), 0644))
+	stdout, _, err := runUpdoc(t, "--api-key", "test-key", "parse", "--retry-failed", manifest)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "No failed files")
+
+	// A retry writes files, so it accepts several formats, and it uses the
+	// options recorded in the manifest unless they are given again
+	data := `{"version":1,"options":{"format":"markdown,json","mode":"enhanced"},"files":[{"source":"/nonexistent/a.pdf","status":"failed"}]}`
+	require.NoError(t, os.WriteFile(manifest, []byte(data), 0644))
+	_, _, err = runUpdoc(t, "--api-key", "test-key", "parse", "--retry-failed", manifest, "-f", "markdown,json")
+	// The recorded source is missing, so the retry gets as far as reading it
+	assert.Equal(t, 4, exitCode(err))
+
+	require.NoError(t, os.WriteFile(manifest, []byte(data), 0644))
+	_, _, err = runUpdoc(t, "--api-key", "test-key", "parse", "--retry-failed", manifest, "--mode", "auto")
+	assert.Equal(t, 4, exitCode(err))
+	saved, err := os.ReadFile(manifest)
+	require.NoError(t, err)
+	assert.Contains(t, string(saved), `"format": "markdown,json"`)
+	assert.Contains(t, string(saved), `"mode": "auto"`)
+}
+
+func TestConvertMirrorsInputTree(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "results")
+	result := `{"content":{"text":"Body"},"elements":[{"id":0,"page":1,"category":"paragraph","content":{"text":"Body"}}]}`
+	for _, name := range []string{"a/report.json", "b/report.json"} {
+		path := filepath.Join(src, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(result), 0644))
+	}
+
+	out := filepath.Join(dir, "mirrored")
+	_, _, err := runUpdoc(t, "convert", src, "-r", "-f", "text", "-d", out)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(out, "a", "report.txt"))
+	assert.FileExists(t, filepath.Join(out, "b", "report.txt"))
+
+	flat := filepath.Join(dir, "flat")
+	_, _, err = runUpdoc(t, "convert", src, "-r", "-f", "text", "-d", flat, "--flat")
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(flat, "report.json.txt"))
+	assert.FileExists(t, filepath.Join(flat, "report.json-2.txt"))
+
+	_, stderr, err := runUpdoc(t, "convert", src, "-r", "-f", "text", "-d", flat, "--flat", "--on-collision", "error")
+	assert.Equal(t, 2, exitCode(err))
+	assert.Contains(t, stderr, "same output name")
+}
+
+func TestParseInvalidOutputTemplate(t *testing.T) {
+	pdfFile := filepath.Join(testdataDir, "dummy.pdf")
+	// The endpoint is unreachable, so any API call would fail with exit code 3
+	args := []string{"--api-key", "test-key", "--endpoint", "http://127.0.0.1:1", "parse"}
+
+	_, stderr, err := runUpdoc(t, append(args, testdataDir, "-d", t.TempDir(), "--output-template", "{name}.md")...)
+	assert.Equal(t, 2, exitCode(err))
+	assert.Contains(t, stderr, "unknown placeholder {name}")
+
+	_, _, err = runUpdoc(t, append(args, testdataDir, "-d", t.TempDir(), "--output-template", "../{stem}.md")...)
+	assert.Equal(t, 2, exitCode(err))
+
+	_, _, err = runUpdoc(t, append(args, pdfFile, "-o", "out/{stem.md")...)
+	assert.Equal(t, 2, exitCode(err))
+}
+
+func TestConvertMultipleFormats(t *testing.T) {
+	dir := t.TempDir()
+	resultFile := filepath.Join(dir, "a.json")
+	result := `{"content":{"markdown":"# Title","text":"Title"},` +
+		`"elements":[{"id":0,"page":1,"category":"heading1","content":{"markdown":"# Title","text":"Title"}}]}`
+	require.NoError(t, os.WriteFile(resultFile, []byte(result), 0644))
+
+	out := filepath.Join(dir, "out", "report.md")
+	_, _, err := runUpdoc(t, "convert", resultFile, "-f", "markdown,text,json", "-o", out)
+	require.NoError(t, err)
+	for _, name := range []string{"report.md", "report.txt", "report.json"} {
+		assert.FileExists(t, filepath.Join(dir, "out", name))
+	}
+
+	_, stderr, err := runUpdoc(t, "convert", resultFile, "-f", "markdown,text")
+	assert.Equal(t, 2, exitCode(err))
+	assert.Contains(t, stderr, "multiple formats")
+
+	_, _, err = runUpdoc(t, "convert", resultFile, "-f", "markdown,pdf", "-o", out)
+	assert.Equal(t, 2, exitCode(err))
+}
+
+func TestJobsCommands(t *testing.T) {
+	jobsDir := t.TempDir()
+	t.Setenv("UPDOC_JOBS_DIR", jobsDir)
+
+	stdout, _, err := runUpdoc(t, "jobs", "list")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "No async requests recorded")
+
+	_, _, err = runUpdoc(t, "--api-key", "test-key", "status", "latest")
+	assert.Equal(t, 2, exitCode(err))
+
+	source := filepath.Join(testdataDir, "dummy.pdf")
+	record := fmt.Sprintf(`{"request_id":"req-e2e","source":%q,"endpoint":"http://127.0.0.1:1","submitted_at":%q,"status":"pending"}`,
+		source, time.Now().Add(-48*time.Hour).Format(time.RFC3339))
+	require.NoError(t, os.WriteFile(filepath.Join(jobsDir, "req-e2e.json"), []byte(record), 0600))
+
+	stdout, _, err = runUpdoc(t, "jobs", "list")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "req-e2e")
+
+	stdout, _, err = runUpdoc(t, "jobs", "show", "latest")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, source)
+
+	stdout, _, err = runUpdoc(t, "jobs", "show", source, "--json")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, `"request_id": "req-e2e"`)
+
+	stdout, _, err = runUpdoc(t, "jobs", "prune", "--older-than", "1d")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Removed 1")
+}